@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"squlito/internal/db"
+	"squlito/internal/importer"
+	"squlito/internal/tableformat"
+)
+
+type importOptions struct {
+	dbPath   string
+	filePath string
+	table    string
+	format   importer.Format
+	showHelp bool
+}
+
+func runImportCommand(args []string, stdout io.Writer, stderr io.Writer, programName string) int {
+	options, err := parseImportArgs(args)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		printImportUsage(stderr, programName)
+		return 2
+	}
+
+	if options.showHelp {
+		printImportUsage(stdout, programName)
+		return 0
+	}
+
+	result, err := runImport(options, stderr)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	_, _ = fmt.Fprintf(stdout, "Imported %d rows into %s\n", result.Rows, result.Table)
+	return 0
+}
+
+func runImport(options importOptions, stderr io.Writer) (result importer.Result, err error) {
+	conn, err := db.OpenOrCreateDatabase(options.dbPath)
+	if err != nil {
+		return result, err
+	}
+	defer func() {
+		closeErr := conn.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	showProgress := isTerminal(stderr)
+	result, err = importer.ImportFile(context.Background(), conn, importer.Options{
+		Path:   options.filePath,
+		Table:  options.table,
+		Format: options.format,
+		Progress: func(progress importer.Progress) {
+			if showProgress {
+				printImportProgress(stderr, filepath.Base(options.filePath), progress)
+			}
+		},
+	})
+	if showProgress {
+		_, _ = fmt.Fprintln(stderr)
+	}
+
+	return result, err
+}
+
+func printImportProgress(stderr io.Writer, label string, progress importer.Progress) {
+	if progress.TotalBytes <= 0 {
+		return
+	}
+
+	percent := min(100, progress.BytesRead*100/progress.TotalBytes)
+	bar := tableformat.ProgressBar(progress.BytesRead, progress.TotalBytes, 30)
+	_, _ = fmt.Fprintf(stderr, "\r%s %s %3d%% %d rows", label, bar, percent, progress.Rows)
+}
+
+func parseImportArgs(args []string) (importOptions, error) {
+	options := importOptions{
+		dbPath:   "",
+		filePath: "",
+		table:    "",
+		format:   "",
+		showHelp: false,
+	}
+
+	formatName := ""
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.Usage = func() {}
+	flags.StringVar(&options.table, "table", "", "table to create")
+	flags.StringVar(&formatName, "format", "", "input format")
+
+	remaining, err := parseInterleaved(flags, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			options.showHelp = true
+			return options, nil
+		}
+		return options, err
+	}
+
+	if formatName != "" {
+		options.format, err = importer.ParseFormat(formatName)
+		if err != nil {
+			return options, err
+		}
+	}
+
+	if len(remaining) == 0 {
+		options.showHelp = true
+		return options, nil
+	}
+
+	if len(remaining) != 2 {
+		return options, fmt.Errorf("expected a database and a file argument, got %d arguments", len(remaining))
+	}
+
+	options.dbPath = remaining[0]
+	options.filePath = remaining[1]
+	return options, nil
+}
+
+func parseInterleaved(flags *flag.FlagSet, args []string) ([]string, error) {
+	positional := []string{}
+	for {
+		err := flags.Parse(args)
+		if err != nil {
+			return nil, err
+		}
+
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func isTerminal(writer io.Writer) bool {
+	file, ok := writer.(*os.File)
+	if !ok {
+		return false
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func printImportUsage(writer io.Writer, programName string) {
+	_, _ = fmt.Fprintf(writer, "Usage:\n  %s import [--help] [--table <name>] [--format <format>] <database> <file>\n\n", programName)
+	_, _ = fmt.Fprintln(writer, "Arguments:")
+	_, _ = fmt.Fprintln(writer, "  database  path to a SQLite database file, created if missing")
+	_, _ = fmt.Fprintln(writer, "  file      CSV, TSV, JSON array or NDJSON file to import")
+	_, _ = fmt.Fprintln(writer, "\nFlags:")
+	_, _ = fmt.Fprintln(writer, "  --help    show this help message")
+	_, _ = fmt.Fprintln(writer, "  --table   name of the table to create (default: the file name)")
+	_, _ = fmt.Fprintln(writer, "  --format  csv, tsv, json or ndjson (default: from the file extension)")
+}
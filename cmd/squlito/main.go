@@ -1,68 +1,162 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	_ "modernc.org/sqlite"
 
 	"squlito/internal/app"
+	"squlito/internal/db"
+	"squlito/internal/history"
+	"squlito/internal/importer"
 )
 
+type cliOptions struct {
+	dbPath    string
+	dataFiles []string
+	write     bool
+	showHelp  bool
+}
+
 func main() {
 	programName := filepath.Base(os.Args[0])
-	dbPath, showHelp, err := parseArgs(os.Args[1:])
+	if len(os.Args) > 1 && os.Args[1] == "query" {
+		os.Exit(runQueryCommand(os.Args[2:], os.Stdin, os.Stdout, os.Stderr, programName))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		os.Exit(runImportCommand(os.Args[2:], os.Stdout, os.Stderr, programName))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "snippets" {
+		os.Exit(runSnippetsCommand(os.Args[2:], os.Stdout, os.Stderr, programName))
+	}
+
+	options, err := parseArgs(os.Args[1:])
 	if err != nil {
 		_, _ = fmt.Fprintln(os.Stderr, err)
 		printUsage(os.Stderr, programName)
 		os.Exit(2)
 	}
 
-	if showHelp {
+	if options.showHelp {
 		printUsage(os.Stdout, programName)
 		return
 	}
 
-	err = app.Run(dbPath)
+	var conn *sql.DB
+	historyKey := ""
+	if len(options.dataFiles) > 0 {
+		conn, err = loadDataFiles(options.dataFiles, os.Stderr)
+		if err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		historyKey = history.DataFilesKey(options.dataFiles)
+	}
+
+	err = app.Run(app.Config{
+		DBPath:     options.dbPath,
+		HistoryKey: historyKey,
+		Writable:   options.write,
+		DB:         conn,
+	})
 	if err != nil {
 		_, _ = fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
 
-func parseArgs(args []string) (string, bool, error) {
+func parseArgs(args []string) (cliOptions, error) {
+	options := cliOptions{
+		dbPath:    "",
+		dataFiles: nil,
+		write:     false,
+		showHelp:  false,
+	}
+
 	flags := flag.NewFlagSet("squlito", flag.ContinueOnError)
 	flags.SetOutput(io.Discard)
 	flags.Usage = func() {}
+	flags.BoolVar(&options.write, "write", false, "open the database read-write")
 
 	err := flags.Parse(args)
 	if err != nil {
 		if errors.Is(err, flag.ErrHelp) {
-			return "", true, nil
+			options.showHelp = true
+			return options, nil
 		}
-		return "", false, err
+		return options, err
 	}
 
 	remaining := flags.Args()
 	if len(remaining) == 0 {
-		return "", true, nil
+		options.showHelp = true
+		return options, nil
+	}
+
+	dataFiles := []string{}
+	for _, path := range remaining {
+		if _, ok := importer.DetectFormat(path); ok {
+			dataFiles = append(dataFiles, path)
+		}
 	}
 
-	if len(remaining) > 1 {
-		return "", false, fmt.Errorf("expected 1 database argument, got %d", len(remaining))
+	if len(dataFiles) == 0 && len(remaining) > 1 {
+		return options, fmt.Errorf("expected 1 database argument, got %d", len(remaining))
+	}
+
+	if len(dataFiles) > 0 && len(dataFiles) < len(remaining) {
+		return options, fmt.Errorf("cannot mix a database with CSV or JSON files")
+	}
+
+	options.dbPath = remaining[0]
+	if len(dataFiles) > 0 {
+		options.dbPath = strings.Join(dataFiles, ", ")
+		options.dataFiles = dataFiles
+	}
+	return options, nil
+}
+
+func loadDataFiles(paths []string, stderr io.Writer) (*sql.DB, error) {
+	conn, err := db.OpenMemoryDatabase(fmt.Sprintf("squlito-%d", os.Getpid()))
+	if err != nil {
+		return nil, err
+	}
+
+	showProgress := isTerminal(stderr)
+	_, err = importer.ImportFiles(context.Background(), conn, paths, func(path string, progress importer.Progress) {
+		if showProgress {
+			printImportProgress(stderr, filepath.Base(path), progress)
+		}
+	})
+	if showProgress {
+		_, _ = fmt.Fprintln(stderr)
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
 	}
 
-	return remaining[0], false, nil
+	return conn, nil
 }
 
 func printUsage(writer io.Writer, programName string) {
-	_, _ = fmt.Fprintf(writer, "Usage:\n  %s [--help] <database>\n\n", programName)
+	_, _ = fmt.Fprintf(writer, "Usage:\n  %s [--help] [--write] <database>\n", programName)
+	_, _ = fmt.Fprintf(writer, "  %s [--help] [--write] <file.csv|file.json|file.ndjson>...\n", programName)
+	_, _ = fmt.Fprintf(writer, "  %s query [--format <format>] <database> [sql]\n", programName)
+	_, _ = fmt.Fprintf(writer, "  %s import [--table <name>] <database> <file>\n", programName)
+	_, _ = fmt.Fprintf(writer, "  %s snippets export|import <file.toml|file.json>\n\n", programName)
 	_, _ = fmt.Fprintln(writer, "Arguments:")
 	_, _ = fmt.Fprintln(writer, "  database  path to a SQLite database file")
+	_, _ = fmt.Fprintln(writer, "  file      CSV, TSV, JSON or NDJSON files, loaded into an in-memory database")
 	_, _ = fmt.Fprintln(writer, "\nFlags:")
 	_, _ = fmt.Fprintln(writer, "  --help    show this help message")
+	_, _ = fmt.Fprintln(writer, "  --write   open the database read-write and allow staged edits")
 }
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"squlito/internal/db"
+	"squlito/internal/export"
+)
+
+type queryOptions struct {
+	dbPath   string
+	sqlText  string
+	format   export.Format
+	showHelp bool
+}
+
+func runQueryCommand(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer, programName string) int {
+	options, err := parseQueryArgs(args)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		printQueryUsage(stderr, programName)
+		return 2
+	}
+
+	if options.showHelp {
+		printQueryUsage(stdout, programName)
+		return 0
+	}
+
+	if options.sqlText == "" {
+		input, readErr := io.ReadAll(stdin)
+		if readErr != nil {
+			_, _ = fmt.Fprintln(stderr, readErr)
+			return 1
+		}
+		options.sqlText = string(input)
+	}
+
+	if strings.TrimSpace(options.sqlText) == "" {
+		_, _ = fmt.Fprintln(stderr, "no SQL given")
+		printQueryUsage(stderr, programName)
+		return 2
+	}
+
+	err = runQuery(options, stdout)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+func runQuery(options queryOptions, stdout io.Writer) (err error) {
+	conn, err := db.OpenDatabase(options.dbPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		closeErr := conn.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	result, err := db.QueryRows(conn, options.sqlText, 0)
+	if err != nil {
+		return err
+	}
+
+	return export.WriteRows(stdout, options.format, export.ResultTableName, result.Columns, result.Rows)
+}
+
+func parseQueryArgs(args []string) (queryOptions, error) {
+	options := queryOptions{
+		dbPath:   "",
+		sqlText:  "",
+		format:   export.FormatTable,
+		showHelp: false,
+	}
+
+	formatName := string(export.FormatTable)
+	flags := flag.NewFlagSet("query", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.Usage = func() {}
+	flags.StringVar(&formatName, "format", formatName, "output format")
+
+	remaining, err := parseInterleaved(flags, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			options.showHelp = true
+			return options, nil
+		}
+		return options, err
+	}
+
+	options.format, err = export.ParseFormat(formatName)
+	if err != nil {
+		return options, err
+	}
+
+	if len(remaining) == 0 {
+		options.showHelp = true
+		return options, nil
+	}
+
+	if len(remaining) > 2 {
+		return options, fmt.Errorf("expected a database and at most 1 SQL argument, got %d arguments", len(remaining))
+	}
+
+	options.dbPath = remaining[0]
+	if len(remaining) == 2 && remaining[1] != "-" {
+		options.sqlText = remaining[1]
+	}
+
+	return options, nil
+}
+
+func printQueryUsage(writer io.Writer, programName string) {
+	formats := []string{}
+	for _, format := range export.Formats {
+		formats = append(formats, string(format))
+	}
+
+	_, _ = fmt.Fprintf(writer, "Usage:\n  %s query [--help] [--format <format>] <database> [sql]\n\n", programName)
+	_, _ = fmt.Fprintln(writer, "Arguments:")
+	_, _ = fmt.Fprintln(writer, "  database  path to a SQLite database file, opened read-only")
+	_, _ = fmt.Fprintln(writer, "  sql       statement to run; read from stdin when omitted or \"-\"")
+	_, _ = fmt.Fprintln(writer, "\nFlags:")
+	_, _ = fmt.Fprintln(writer, "  --help    show this help message")
+	_, _ = fmt.Fprintf(writer, "  --format  output format: %s (default table)\n", strings.Join(formats, ", "))
+}
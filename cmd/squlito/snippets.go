@@ -0,0 +1,213 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"squlito/internal/history"
+	"squlito/internal/snippets"
+)
+
+type snippetsOptions struct {
+	action   string
+	filePath string
+	dbPath   string
+	showHelp bool
+}
+
+func runSnippetsCommand(args []string, stdout io.Writer, stderr io.Writer, programName string) int {
+	options, err := parseSnippetsArgs(args)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		printSnippetsUsage(stderr, programName)
+		return 2
+	}
+
+	if options.showHelp {
+		printSnippetsUsage(stdout, programName)
+		return 0
+	}
+
+	count, err := runSnippets(options)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	if options.action == "export" {
+		_, _ = fmt.Fprintf(stdout, "Exported %d snippets to %s\n", count, options.filePath)
+		return 0
+	}
+
+	_, _ = fmt.Fprintf(stdout, "Imported %d snippets from %s\n", count, options.filePath)
+	return 0
+}
+
+func runSnippets(options snippetsOptions) (count int, err error) {
+	format, err := snippets.FormatFromPath(options.filePath)
+	if err != nil {
+		return 0, err
+	}
+
+	conn, err := history.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		closeErr := conn.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	err = snippets.EnsureSchema(conn)
+	if err != nil {
+		return 0, err
+	}
+
+	libraryPath, err := filepath.Abs(options.filePath)
+	if err != nil {
+		return 0, err
+	}
+	baseDir := filepath.Dir(libraryPath)
+
+	if options.action == "export" {
+		database := ""
+		if options.dbPath != "" {
+			database = history.DatabaseKey(options.dbPath)
+		}
+
+		library, err := snippets.List(conn, database)
+		if err != nil {
+			return 0, err
+		}
+		for index := range library {
+			library[index].Database = snippets.PortableDatabase(library[index].Database, baseDir)
+		}
+
+		return len(library), writeSnippetsFile(options.filePath, format, library)
+	}
+
+	file, err := os.Open(options.filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	library, err := snippets.Read(file, format)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", options.filePath, err)
+	}
+
+	for index := range library {
+		library[index].Database = snippets.ResolveDatabase(library[index].Database, baseDir)
+	}
+
+	err = snippets.SaveAll(conn, library)
+	if err != nil {
+		return 0, fmt.Errorf("%s: nothing imported: %w", options.filePath, err)
+	}
+
+	return len(library), nil
+}
+
+// writeSnippetsFile writes through a temp file next to path so a failed export
+// leaves an existing library untouched.
+func writeSnippetsFile(path string, format snippets.Format, library []snippets.Snippet) (err error) {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		closeErr := file.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Rename(file.Name(), path)
+		}
+		if err != nil {
+			_ = os.Remove(file.Name())
+		}
+	}()
+
+	mode := os.FileMode(0o644)
+	info, err := os.Stat(path)
+	if err == nil {
+		mode = info.Mode().Perm()
+	}
+	err = file.Chmod(mode)
+	if err != nil {
+		return err
+	}
+
+	err = snippets.Write(file, format, library)
+	if err != nil {
+		return err
+	}
+
+	return file.Sync()
+}
+
+func parseSnippetsArgs(args []string) (snippetsOptions, error) {
+	options := snippetsOptions{
+		action:   "",
+		filePath: "",
+		dbPath:   "",
+		showHelp: false,
+	}
+
+	flags := flag.NewFlagSet("snippets", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.Usage = func() {}
+	flags.StringVar(&options.dbPath, "database", "", "only export snippets visible for this database")
+
+	remaining, err := parseInterleaved(flags, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			options.showHelp = true
+			return options, nil
+		}
+		return options, err
+	}
+
+	if len(remaining) == 0 {
+		options.showHelp = true
+		return options, nil
+	}
+
+	options.action = remaining[0]
+	if options.action != "export" && options.action != "import" {
+		return options, fmt.Errorf("unknown snippets action %q (expected export or import)", options.action)
+	}
+
+	if len(remaining) != 2 {
+		return options, fmt.Errorf("expected a file argument after %s", options.action)
+	}
+
+	if options.action == "import" && options.dbPath != "" {
+		return options, fmt.Errorf("--database only applies to export")
+	}
+
+	options.filePath = remaining[1]
+	return options, nil
+}
+
+func printSnippetsUsage(writer io.Writer, programName string) {
+	_, _ = fmt.Fprintf(writer, "Usage:\n  %s snippets export [--help] [--database <database>] <file>\n", programName)
+	_, _ = fmt.Fprintf(writer, "  %s snippets import [--help] <file>\n\n", programName)
+	_, _ = fmt.Fprintln(writer, "Arguments:")
+	_, _ = fmt.Fprintln(writer, "  file        .toml or .json snippet library")
+	_, _ = fmt.Fprintln(writer, "\nFlags:")
+	_, _ = fmt.Fprintln(writer, "  --help      show this help message")
+	_, _ = fmt.Fprintln(writer, "  --database  export only global snippets and those saved for this database")
+	_, _ = fmt.Fprintln(writer, "\nPer-database snippets are stored relative to the snippet file, so a library")
+	_, _ = fmt.Fprintln(writer, "kept next to the database works on every checkout. Importing replaces snippets")
+	_, _ = fmt.Fprintln(writer, "with the same name and database, and imports nothing if any snippet fails.")
+}
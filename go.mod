@@ -3,6 +3,7 @@ module squlito
 go 1.25.5
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/awesome-gocui/gocui v1.1.0
 	modernc.org/sqlite v1.44.3
 )
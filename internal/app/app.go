@@ -1,16 +1,27 @@
 package app
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/awesome-gocui/gocui"
 
 	"squlito/internal/db"
+	"squlito/internal/history"
 )
 
+type Config struct {
+	DBPath     string
+	HistoryKey string
+	Writable   bool
+	DB         *sql.DB
+}
+
 type App struct {
 	dbPath    string
+	writable  bool
 	db        *sql.DB
 	gui       *gocui.Gui
 	historyDB *sql.DB
@@ -18,14 +29,35 @@ type App struct {
 	focusArea FocusArea
 	viewMode  ViewMode
 
-	tables             []db.SqliteTable
-	selectedTableIndex int
+	schema           []db.SchemaObject
+	tables           []db.SqliteTable
+	sidebarIndex     int
+	tableHistory     []tableLocation
+	sidebarExpanded  map[string]bool
+	sidebarCollapsed map[string]bool
 
 	tableState     TableState
-	queryState     QueryState
-	historyEntries []QueryHistoryEntry
+	queryState     *QueryState
+	queryTabs      []*QueryTab
+	activeTab      int
+	nextTabID      int
+	textState      TextState
+	searchTerm     string
+	searchCancel   context.CancelFunc
+	searchID       int
+	searchWaiting  *searchScan
+	spinnerCancel  context.CancelFunc
+	exportState    ExportState
+	exportCancel   context.CancelFunc
+	importState    ImportState
+	importCancel   context.CancelFunc
+	historyEntries []history.Entry
 	historyIndex   int
 	historyDraft   string
+	parameters     map[string]string
+
+	historyDatabase     string
+	historyAllDatabases bool
 
 	scrollState   ScrollState
 	scrollX       int
@@ -37,17 +69,31 @@ type App struct {
 	modalTitle     string
 	modalBody      string
 	modalScroll    int
+	modalItems     []pickerItem
+	modalIndex     int
 	modalPrevFocus FocusArea
+
+	prompt PromptState
+
+	completion        CompletionState
+	historyBrowser    HistoryBrowserState
+	completionColumns map[string][]string
+
+	pendingChanges []db.RowChange
+	quitArmed      bool
+
+	statusMessage   string
+	statusMessageAt time.Time
 }
 
-func Run(dbPath string) error {
+func Run(config Config) error {
 	gui, err := gocui.NewGui(gocui.OutputNormal, false)
 	if err != nil {
 		return err
 	}
 	defer gui.Close()
 
-	app := NewApp(dbPath, gui)
+	app := NewApp(config, gui)
 	err = app.Init()
 	if err != nil {
 		return err
@@ -75,38 +121,81 @@ func Run(dbPath string) error {
 	return nil
 }
 
-func NewApp(dbPath string, gui *gocui.Gui) *App {
+func NewApp(config Config, gui *gocui.Gui) *App {
+	firstTab := newQueryTab(1)
 	return &App{
-		dbPath:             dbPath,
-		db:                 nil,
-		gui:                gui,
-		historyDB:          nil,
-		focusArea:          focusSidebar,
-		viewMode:           viewTable,
-		tables:             nil,
-		selectedTableIndex: 0,
+		dbPath:           config.DBPath,
+		writable:         config.Writable,
+		db:               config.DB,
+		gui:              gui,
+		historyDB:        nil,
+		focusArea:        focusSidebar,
+		viewMode:         viewTable,
+		schema:           nil,
+		tables:           nil,
+		sidebarIndex:     0,
+		tableHistory:     nil,
+		sidebarExpanded:  map[string]bool{},
+		sidebarCollapsed: map[string]bool{},
 		tableState: TableState{
-			Name:        "",
-			TotalRows:   0,
-			Offset:      0,
-			BufferStart: 0,
-			BufferSize:  bufferSize,
-			Rows:        nil,
-			Columns:     nil,
-			Error:       "",
+			Name:           "",
+			TotalRows:      0,
+			Offset:         0,
+			CursorRow:      0,
+			CursorCol:      0,
+			BufferStart:    0,
+			BufferSize:     bufferSize,
+			Rows:           nil,
+			Columns:        nil,
+			KeyColumns:     nil,
+			ObjectType:     "",
+			PageKeyColumns: nil,
+			Anchors:        nil,
+			Filter:         TableFilter{Where: ""},
+			Sort:           nil,
+			Stale:          false,
+			Error:          "",
 		},
-		queryState: QueryState{
-			SQL:       "",
-			AllRows:   nil,
-			Columns:   nil,
-			Error:     "",
+		queryState:    &firstTab.State,
+		queryTabs:     []*QueryTab{firstTab},
+		activeTab:     0,
+		nextTabID:     2,
+		searchTerm:    "",
+		searchCancel:  nil,
+		searchID:      0,
+		searchWaiting: nil,
+		textState: TextState{
+			Kind:   "",
+			Title:  "",
+			Body:   "",
+			Styled: "",
+			Offset: 0,
+		},
+		spinnerCancel: nil,
+		exportState: ExportState{
 			Running:   false,
-			Truncated: false,
-			Offset:    0,
+			Path:      "",
+			Rows:      0,
+			Total:     0,
+			StartedAt: time.Time{},
+		},
+		exportCancel: nil,
+		importState: ImportState{
+			Running:    false,
+			Path:       "",
+			Table:      "",
+			Rows:       0,
+			BytesRead:  0,
+			TotalBytes: 0,
+			StartedAt:  time.Time{},
 		},
-		historyEntries: nil,
-		historyIndex:   -1,
-		historyDraft:   "",
+		importCancel:        nil,
+		historyEntries:      nil,
+		historyIndex:        -1,
+		historyDraft:        "",
+		parameters:          map[string]string{},
+		historyDatabase:     config.HistoryKey,
+		historyAllDatabases: false,
 		scrollState: ScrollState{
 			OverflowY:         false,
 			OverflowX:         false,
@@ -121,32 +210,69 @@ func NewApp(dbPath string, gui *gocui.Gui) *App {
 		modalTitle:          "",
 		modalBody:           "",
 		modalScroll:         0,
+		modalItems:          nil,
+		modalIndex:          0,
 		modalPrevFocus:      focusSidebar,
+		prompt: PromptState{
+			Open:      false,
+			Title:     "",
+			Value:     "",
+			PrevFocus: focusSidebar,
+			OnSubmit:  nil,
+		},
+		completion: CompletionState{
+			Open:   false,
+			Prefix: "",
+			Items:  nil,
+			Index:  0,
+			Scroll: 0,
+		},
+		historyBrowser: HistoryBrowserState{
+			Open:      false,
+			Query:     "",
+			All:       nil,
+			Matches:   nil,
+			Index:     0,
+			Scroll:    0,
+			PrevFocus: focusSidebar,
+		},
+		completionColumns: map[string][]string{},
+		pendingChanges:    nil,
+		quitArmed:         false,
+		statusMessage:     "",
+		statusMessageAt:   time.Time{},
 	}
 }
 
 func (app *App) Init() error {
-	dbConn, err := db.OpenDatabase(app.dbPath)
-	if err != nil {
-		app.tableState.Error = err.Error()
-		return err
+	if app.db == nil {
+		open := db.OpenDatabase
+		if app.writable {
+			open = db.OpenWritableDatabase
+		}
+
+		dbConn, err := open(app.dbPath)
+		if err != nil {
+			app.tableState.Error = err.Error()
+			return err
+		}
+
+		app.db = dbConn
 	}
 
-	app.db = dbConn
 	app.initHistory()
 
-	tables, err := db.ListUserTables(app.db)
+	err := app.loadSchema()
 	if err != nil {
 		app.tableState.Error = err.Error()
 		return err
 	}
 
-	app.tables = tables
-	if len(tables) == 0 {
+	if len(app.tables) == 0 {
 		return nil
 	}
 
-	err = app.setSelectedTable(0)
+	err = app.selectFirstTable()
 	if err != nil {
 		return err
 	}
@@ -155,6 +281,14 @@ func (app *App) Init() error {
 }
 
 func (app *App) Close() {
+	app.stopSpinner()
+	app.cancelSearch()
+	for _, tab := range app.queryTabs {
+		discardQueryRows(tab)
+	}
+	app.cancelExport()
+	app.cancelImport()
+
 	if app.db != nil {
 		err := app.db.Close()
 		if err != nil {
@@ -206,6 +340,47 @@ func (app *App) layout(gui *gocui.Gui) error {
 		app.clearModal(gui)
 	}
 
+	if app.historyBrowser.Open {
+		err = app.layoutHistoryBrowser(gui, maxX, maxY)
+		if err != nil {
+			return err
+		}
+
+		if app.focusArea == focusHistory {
+			err = app.setFocus(focusHistory)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		app.clearHistoryBrowser(gui)
+	}
+
+	if app.completion.Open {
+		err = app.layoutCompletion(gui, maxX)
+		if err != nil {
+			return err
+		}
+	} else {
+		app.clearCompletion(gui)
+	}
+
+	if app.prompt.Open {
+		err = app.layoutPrompt(gui, maxX, maxY)
+		if err != nil {
+			return err
+		}
+
+		if app.focusArea == focusPrompt {
+			err = app.setFocus(focusPrompt)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		app.clearPrompt(gui)
+	}
+
 	return app.render()
 }
 
@@ -227,7 +402,7 @@ func (app *App) layoutViews(gui *gocui.Gui, metrics layoutMetrics, maxX int, max
 		return err
 	}
 	if err == gocui.ErrUnknownView {
-		sidebarView.Title = "Tables"
+		sidebarView.Title = "Schema"
 		sidebarView.Wrap = false
 	}
 
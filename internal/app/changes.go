@@ -0,0 +1,325 @@
+package app
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"squlito/internal/db"
+	"squlito/internal/tableformat"
+)
+
+type rowTarget struct {
+	insertIndex int
+	key         db.RowKey
+}
+
+func (app *App) requireEditableTable() bool {
+	if !app.writable {
+		app.setStatusMessage("Read-only: reopen with --write to edit")
+		return false
+	}
+
+	if app.viewMode != viewTable || app.tableState.Name == "" {
+		app.setStatusMessage("Editing is only available when browsing a table")
+		return false
+	}
+
+	if app.tableState.ObjectType == db.ObjectView {
+		app.setStatusMessage("Views are read-only")
+		return false
+	}
+
+	if len(app.tableState.KeyColumns) == 0 {
+		app.setStatusMessage("Table has no primary key or rowid to identify rows")
+		return false
+	}
+
+	return true
+}
+
+func (app *App) editCellAtCursor() error {
+	if !app.requireEditableTable() {
+		return nil
+	}
+
+	cell, ok := app.cellAtCursor()
+	if !ok {
+		return nil
+	}
+
+	target, ok := app.rowTargetAt(cell.RowIndex)
+	if !ok {
+		return nil
+	}
+
+	if target.insertIndex < 0 && app.findPendingChange(db.ChangeDelete, target.key) >= 0 {
+		app.setStatusMessage("Row is marked for deletion")
+		return nil
+	}
+
+	if _, isBlob := cell.Value.([]byte); isBlob {
+		app.setStatusMessage("BLOB values cannot be edited")
+		return nil
+	}
+
+	column := cell.Column
+	return app.openPrompt("Edit "+column+" (NULL for null)", tableformat.FormatCell(cell.Value), func(value string) error {
+		app.stageCellEdit(target, column, parseEditValue(value))
+		return nil
+	})
+}
+
+func (app *App) stageCellEdit(target rowTarget, column string, value db.SqliteValue) {
+	if target.insertIndex >= 0 {
+		app.pendingChanges[target.insertIndex].Values[column] = value
+		return
+	}
+
+	original, _ := app.findBufferRow(target.key)
+	unchanged := original != nil && tableformat.FormatCell(original[column]) == tableformat.FormatCell(value)
+
+	index := app.findPendingChange(db.ChangeUpdate, target.key)
+	if index < 0 {
+		if unchanged {
+			return
+		}
+
+		app.pendingChanges = append(app.pendingChanges, db.RowChange{
+			Kind:   db.ChangeUpdate,
+			Table:  app.tableState.Name,
+			Key:    target.key,
+			Values: map[string]db.SqliteValue{column: value},
+		})
+		return
+	}
+
+	change := app.pendingChanges[index]
+	if !unchanged {
+		change.Values[column] = value
+		return
+	}
+
+	delete(change.Values, column)
+	if len(change.Values) == 0 {
+		app.removePendingChange(index)
+	}
+}
+
+func (app *App) stageInsertRow() {
+	if !app.requireEditableTable() {
+		return
+	}
+
+	app.pendingChanges = append(app.pendingChanges, db.RowChange{
+		Kind:   db.ChangeInsert,
+		Table:  app.tableState.Name,
+		Key:    db.RowKey{Columns: nil, Values: nil},
+		Values: map[string]db.SqliteValue{},
+	})
+	app.tableState.CursorRow = app.currentRowCount() - 1
+	app.revealCursor()
+}
+
+func (app *App) toggleDeleteAtCursor() {
+	if !app.requireEditableTable() {
+		return
+	}
+
+	cell, ok := app.cellAtCursor()
+	if !ok {
+		return
+	}
+
+	target, ok := app.rowTargetAt(cell.RowIndex)
+	if !ok {
+		return
+	}
+
+	if target.insertIndex >= 0 {
+		app.removePendingChange(target.insertIndex)
+		return
+	}
+
+	index := app.findPendingChange(db.ChangeDelete, target.key)
+	if index >= 0 {
+		app.removePendingChange(index)
+		return
+	}
+
+	index = app.findPendingChange(db.ChangeUpdate, target.key)
+	if index >= 0 {
+		app.removePendingChange(index)
+	}
+
+	app.pendingChanges = append(app.pendingChanges, db.RowChange{
+		Kind:   db.ChangeDelete,
+		Table:  app.tableState.Name,
+		Key:    target.key,
+		Values: nil,
+	})
+}
+
+func (app *App) commitChanges() error {
+	if !app.writable {
+		app.setStatusMessage("Read-only: reopen with --write to edit")
+		return nil
+	}
+
+	if len(app.pendingChanges) == 0 {
+		app.setStatusMessage("No pending changes")
+		return nil
+	}
+
+	app.stopQueryStreams()
+
+	count := len(app.pendingChanges)
+	err := db.ApplyChanges(app.db, app.pendingChanges)
+	if err != nil {
+		return app.openModal("Commit failed", err.Error())
+	}
+
+	app.pendingChanges = nil
+	app.quitArmed = false
+	app.setStatusMessage(fmt.Sprintf("Committed %d changes", count))
+	app.tableState.Stale = true
+	_ = app.reloadTableBuffer()
+	return nil
+}
+
+func (app *App) discardChanges() {
+	if len(app.pendingChanges) == 0 {
+		return
+	}
+
+	count := len(app.pendingChanges)
+	app.pendingChanges = nil
+	app.quitArmed = false
+	app.setStatusMessage(fmt.Sprintf("Discarded %d changes", count))
+}
+
+func (app *App) rowTargetAt(rowIndex int) (target rowTarget, ok bool) {
+	rows := app.tableState.Rows
+	if rowIndex < len(rows) {
+		key, ok := db.RowKeyFor(app.tableState.KeyColumns, rows[rowIndex])
+		if !ok {
+			return target, false
+		}
+		return rowTarget{insertIndex: -1, key: key}, true
+	}
+
+	inserts := app.pendingInsertIndexes()
+	insertOffset := rowIndex - len(rows)
+	if !app.bufferReachesTableEnd() || insertOffset >= len(inserts) {
+		return target, false
+	}
+
+	return rowTarget{insertIndex: inserts[insertOffset], key: db.RowKey{Columns: nil, Values: nil}}, true
+}
+
+func (app *App) displayTableRows() []db.SqliteRow {
+	rows := app.tableState.Rows
+	if len(app.pendingChanges) == 0 {
+		return rows
+	}
+
+	display := make([]db.SqliteRow, 0, len(rows))
+	for _, row := range rows {
+		key, ok := db.RowKeyFor(app.tableState.KeyColumns, row)
+		index := -1
+		if ok {
+			index = app.findPendingChange(db.ChangeUpdate, key)
+		}
+		if index < 0 {
+			display = append(display, row)
+			continue
+		}
+
+		merged := maps.Clone(row)
+		maps.Copy(merged, app.pendingChanges[index].Values)
+		display = append(display, merged)
+	}
+
+	if !app.bufferReachesTableEnd() {
+		return display
+	}
+
+	for _, index := range app.pendingInsertIndexes() {
+		display = append(display, db.SqliteRow(app.pendingChanges[index].Values))
+	}
+
+	return display
+}
+
+func (app *App) pendingCellStyle(rowIndex int, colIndex int) string {
+	target, ok := app.rowTargetAt(rowIndex)
+	if !ok {
+		return ""
+	}
+
+	if target.insertIndex >= 0 {
+		return tableformat.StyleInserted
+	}
+
+	if app.findPendingChange(db.ChangeDelete, target.key) >= 0 {
+		return tableformat.StyleDeleted
+	}
+
+	index := app.findPendingChange(db.ChangeUpdate, target.key)
+	if index < 0 || colIndex >= len(app.tableState.Columns) {
+		return ""
+	}
+
+	_, edited := app.pendingChanges[index].Values[app.tableState.Columns[colIndex]]
+	if edited {
+		return tableformat.StyleEdited
+	}
+
+	return ""
+}
+
+func (app *App) pendingInsertIndexes() []int {
+	indexes := []int{}
+	for i, change := range app.pendingChanges {
+		if change.Kind == db.ChangeInsert && change.Table == app.tableState.Name {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+func (app *App) bufferReachesTableEnd() bool {
+	return app.tableState.BufferStart+len(app.tableState.Rows) >= app.tableState.TotalRows
+}
+
+func (app *App) findPendingChange(kind db.ChangeKind, key db.RowKey) int {
+	for i, change := range app.pendingChanges {
+		if change.Kind == kind && change.Table == app.tableState.Name && change.Key.Equal(key) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (app *App) findBufferRow(key db.RowKey) (db.SqliteRow, bool) {
+	for _, row := range app.tableState.Rows {
+		rowKey, ok := db.RowKeyFor(app.tableState.KeyColumns, row)
+		if ok && rowKey.Equal(key) {
+			return row, true
+		}
+	}
+	return nil, false
+}
+
+func (app *App) removePendingChange(index int) {
+	app.pendingChanges = slices.Delete(app.pendingChanges, index, index+1)
+}
+
+func parseEditValue(value string) db.SqliteValue {
+	if strings.EqualFold(strings.TrimSpace(value), "NULL") {
+		return nil
+	}
+
+	return value
+}
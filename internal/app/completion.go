@@ -0,0 +1,224 @@
+package app
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/awesome-gocui/gocui"
+
+	"squlito/internal/completion"
+	"squlito/internal/db"
+)
+
+const completionViewName = "completion"
+
+func (app *App) layoutCompletion(gui *gocui.Gui, maxX int) error {
+	queryView, err := gui.View("query")
+	if err != nil {
+		return nil
+	}
+
+	width := min(max(completionTextWidth(app.completion.Items)+len(completion.KindFunction)+5, 20), maxX-2)
+	height := min(len(app.completion.Items), completionMaxItems) + 2
+
+	queryX0, queryY0, queryX1, _ := queryView.Dimensions()
+	cursorX, _ := queryView.Cursor()
+	innerWidth := max(queryX1-queryX0-1, 1)
+	column := cursorX%innerWidth - utf8.RuneCountInString(app.completion.Prefix)
+
+	x0 := clampInt(queryX0+column, 0, max(maxX-width, 0))
+	y1 := queryY0
+	y0 := y1 - height + 1
+	if width < 2 || y0 < 0 {
+		return nil
+	}
+
+	view, err := gui.SetView(completionViewName, x0, y0, x0+width-1, y1, 0)
+	if err != nil && err != gocui.ErrUnknownView {
+		return err
+	}
+	if err == gocui.ErrUnknownView {
+		view.Wrap = false
+		view.Frame = true
+		view.FrameColor = gocui.ColorGreen
+	}
+
+	_, _ = gui.SetViewOnTop(completionViewName)
+	return nil
+}
+
+func (app *App) clearCompletion(gui *gocui.Gui) {
+	if gui == nil {
+		return
+	}
+
+	_, err := gui.View(completionViewName)
+	if err != nil {
+		return
+	}
+
+	_ = gui.DeleteView(completionViewName)
+}
+
+func (app *App) openCompletion(view *gocui.View, explicit bool) (bool, error) {
+	if view == nil {
+		return false, nil
+	}
+
+	result := app.completeQuery(view)
+	if !explicit && result.Prefix == "" && result.Qualifier == "" {
+		return false, nil
+	}
+
+	if len(result.Items) == 0 {
+		app.closeCompletion()
+		app.setStatusMessage("No completions")
+		return true, app.render()
+	}
+
+	if len(result.Items) == 1 {
+		insertCompletion(view, result.Prefix, result.Items[0])
+		app.closeCompletion()
+		app.resetHistorySelection()
+		return true, app.render()
+	}
+
+	app.completion = CompletionState{
+		Open:   true,
+		Prefix: result.Prefix,
+		Items:  result.Items,
+		Index:  0,
+		Scroll: 0,
+	}
+	return true, app.render()
+}
+
+func (app *App) refreshCompletion(view *gocui.View) {
+	if !app.completion.Open {
+		return
+	}
+
+	result := app.completeQuery(view)
+	if len(result.Items) == 0 || (result.Prefix == "" && result.Qualifier == "") {
+		app.closeCompletion()
+		return
+	}
+
+	app.completion.Prefix = result.Prefix
+	app.completion.Items = result.Items
+	app.completion.Index = 0
+	app.completion.Scroll = 0
+}
+
+func (app *App) acceptCompletion(view *gocui.View) error {
+	if !app.completion.Open || len(app.completion.Items) == 0 {
+		return nil
+	}
+
+	item := app.completion.Items[app.completion.Index]
+	insertCompletion(view, app.completion.Prefix, item)
+	app.closeCompletion()
+	app.resetHistorySelection()
+	return app.render()
+}
+
+func (app *App) moveCompletionSelection(delta int) error {
+	count := len(app.completion.Items)
+	if count == 0 {
+		return nil
+	}
+
+	app.completion.Index = ((app.completion.Index+delta)%count + count) % count
+	return app.render()
+}
+
+func (app *App) closeCompletion() {
+	app.completion = CompletionState{
+		Open:   false,
+		Prefix: "",
+		Items:  nil,
+		Index:  0,
+		Scroll: 0,
+	}
+}
+
+func (app *App) completeQuery(view *gocui.View) completion.Result {
+	text, cursor := queryTextAtCursor(view)
+	tables := make([]string, 0, len(app.tables))
+	for _, table := range app.tables {
+		tables = append(tables, table.Name)
+	}
+
+	return completion.Complete(text, cursor, completion.Schema{
+		Tables:  tables,
+		Columns: app.completionColumnNames,
+	})
+}
+
+func (app *App) completionColumnNames(table string) []string {
+	if names, ok := app.completionColumns[table]; ok {
+		return names
+	}
+
+	names := []string{}
+	if app.isKnownTable(table) {
+		columns, err := db.GetTableColumns(app.db, table)
+		if err == nil {
+			for _, column := range columns {
+				names = append(names, column.Name)
+			}
+		}
+	}
+
+	app.completionColumns[table] = names
+	return names
+}
+
+func (app *App) isKnownTable(name string) bool {
+	for _, table := range app.tables {
+		if table.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func queryTextAtCursor(view *gocui.View) (text string, cursor int) {
+	lines := view.BufferLines()
+	cursorX, cursorY := view.Cursor()
+	for index, line := range lines {
+		if index > 0 {
+			text += "\n"
+		}
+		if index == cursorY {
+			runes := []rune(line)
+			cursor = len(text) + len(string(runes[:min(cursorX, len(runes))]))
+		}
+		text += line
+	}
+
+	return text, cursor
+}
+
+func insertCompletion(view *gocui.View, prefix string, item completion.Item) {
+	for range utf8.RuneCountInString(prefix) {
+		view.EditDelete(true)
+	}
+	for _, r := range item.Insert() {
+		view.EditWrite(r)
+	}
+	highlightQueryView(view)
+}
+
+func completionTextWidth(items []completion.Item) int {
+	width := 0
+	for _, item := range items {
+		width = max(width, utf8.RuneCountInString(item.Text))
+	}
+	return width
+}
+
+func completionLabel(item completion.Item, width int) string {
+	padding := max(width-utf8.RuneCountInString(item.Text), 1)
+	return item.Text + strings.Repeat(" ", padding) + string(item.Kind)
+}
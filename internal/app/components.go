@@ -19,13 +19,13 @@ func Sidebar(app *App, view *gocui.View) {
 	app.updateSidebarScroll(height)
 	_ = view.SetOrigin(0, app.sidebarScroll)
 
-	for index, table := range app.tables {
+	for index, item := range app.sidebarItems() {
 		prefix := "  "
-		if index == app.selectedTableIndex {
+		if index == app.sidebarIndex {
 			prefix = "> "
 		}
 
-		line := prefix + table.Name
+		line := prefix + sidebarLine(item)
 		_, _ = fmt.Fprintln(view, line)
 	}
 }
@@ -45,9 +45,12 @@ func RowsBody(app *App, view *gocui.View, tableView tableformat.TableRender, vie
 	view.Clear()
 
 	rowScrollDelta := 0
-	if app.viewMode == viewTable {
+	switch app.viewMode {
+	case viewTable:
 		rowScrollDelta = viewOffset - app.tableState.BufferStart
-	} else {
+	case viewQuery:
+		rowScrollDelta = viewOffset - app.queryState.WindowStart
+	default:
 		rowScrollDelta = viewOffset
 	}
 
@@ -69,12 +72,45 @@ func RowsBody(app *App, view *gocui.View, tableView tableformat.TableRender, vie
 }
 
 func QueryPanel(app *App, view *gocui.View) {
+	prefix := ""
+	if len(app.queryTabs) > 1 {
+		prefix = fmt.Sprintf("%d: ", app.currentQueryTab().ID)
+	}
+
+	suffix := ""
+	if label := unbalancedQuoteLabel(view.Buffer()); label != "" {
+		suffix = " [" + label + "]"
+	}
+
 	if app.queryState.SQL == "" {
-		view.Title = "Query"
+		view.Title = prefix + "Query" + suffix
 		return
 	}
 
-	view.Title = truncateTitle(app.queryState.SQL)
+	view.Title = prefix + truncateTitle(app.queryState.SQL) + suffix
+}
+
+func CompletionList(app *App, view *gocui.View) {
+	view.Clear()
+
+	_, height := view.Size()
+	if app.completion.Index < app.completion.Scroll {
+		app.completion.Scroll = app.completion.Index
+	}
+	if height > 0 && app.completion.Index >= app.completion.Scroll+height {
+		app.completion.Scroll = app.completion.Index - height + 1
+	}
+
+	_ = view.SetOrigin(0, app.completion.Scroll)
+	width := completionTextWidth(app.completion.Items) + 1
+	for index, item := range app.completion.Items {
+		prefix := "  "
+		if index == app.completion.Index {
+			prefix = "> "
+		}
+
+		_, _ = fmt.Fprintln(view, prefix+completionLabel(item, width))
+	}
 }
 
 func StatusBar(app *App, view *gocui.View) {
@@ -91,10 +127,71 @@ func Modal(app *App, view *gocui.View) {
 	view.Clear()
 	view.Title = app.modalTitle
 
+	if len(app.modalItems) > 0 {
+		_, height := view.Size()
+		if app.modalIndex < app.modalScroll {
+			app.modalScroll = app.modalIndex
+		}
+		if height > 0 && app.modalIndex >= app.modalScroll+height {
+			app.modalScroll = app.modalIndex - height + 1
+		}
+	}
+
 	if app.modalScroll < 0 {
 		app.modalScroll = 0
 	}
 
 	_ = view.SetOrigin(0, app.modalScroll)
-	_, _ = fmt.Fprint(view, app.modalBody)
+	if len(app.modalItems) == 0 {
+		_, _ = fmt.Fprint(view, app.modalBody)
+		return
+	}
+
+	for index, item := range app.modalItems {
+		prefix := "  "
+		if index == app.modalIndex {
+			prefix = "> "
+		}
+
+		_, _ = fmt.Fprintln(view, prefix+item.Label)
+	}
+}
+
+func HistoryBrowser(app *App, searchView *gocui.View, listView *gocui.View, previewView *gocui.View) {
+	browser := &app.historyBrowser
+	scope := "this database"
+	if app.historyAllDatabases {
+		scope = "all databases"
+	}
+	searchView.Title = fmt.Sprintf("History search: %s (%d of %d)", scope, len(browser.Matches), len(browser.All))
+
+	listView.Clear()
+	width, height := listView.Size()
+	if browser.Index < browser.Scroll {
+		browser.Scroll = browser.Index
+	}
+	if height > 0 && browser.Index >= browser.Scroll+height {
+		browser.Scroll = browser.Index - height + 1
+	}
+
+	_ = listView.SetOrigin(0, browser.Scroll)
+	for index, entry := range browser.Matches {
+		prefix := "  "
+		if index == browser.Index {
+			prefix = "> "
+		}
+
+		_, _ = fmt.Fprintln(listView, prefix+historyEntryLabel(entry, width-2, app.historyAllDatabases))
+	}
+
+	previewView.Clear()
+	entry, ok := app.selectedHistoryEntry()
+	if !ok {
+		previewView.Title = "Preview"
+		_, _ = fmt.Fprint(previewView, "No matching queries")
+		return
+	}
+
+	previewView.Title = "Last run " + formatHistoryTime(entry.LastRunAt)
+	_, _ = fmt.Fprint(previewView, historyEntryPreview(entry))
 }
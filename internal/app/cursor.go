@@ -0,0 +1,159 @@
+package app
+
+import (
+	"github.com/awesome-gocui/gocui"
+
+	"squlito/internal/tableformat"
+)
+
+func (app *App) cursorPosition() (int, int) {
+	if app.viewMode == viewQuery {
+		return app.queryState.CursorRow, app.queryState.CursorCol
+	}
+
+	return app.tableState.CursorRow, app.tableState.CursorCol
+}
+
+func (app *App) setCursorPosition(row int, col int) {
+	if app.viewMode == viewQuery {
+		app.queryState.CursorRow = row
+		app.queryState.CursorCol = col
+		return
+	}
+
+	app.tableState.CursorRow = row
+	app.tableState.CursorCol = col
+}
+
+func (app *App) cursorRowBase() int {
+	if app.viewMode == viewQuery {
+		return app.queryState.WindowStart
+	}
+
+	return app.tableState.BufferStart
+}
+
+func (app *App) currentColumns() []string {
+	if app.viewMode == viewQuery {
+		return app.queryState.Columns
+	}
+
+	return app.tableState.Columns
+}
+
+func (app *App) setCurrentOffset(offset int) {
+	if app.viewMode == viewQuery {
+		app.queryState.Offset = offset
+		return
+	}
+
+	app.tableState.Offset = offset
+}
+
+func (app *App) moveCursor(rowDelta int, colDelta int) error {
+	rowCount := app.currentRowCount()
+	colCount := len(app.currentColumns())
+	if rowCount == 0 || colCount == 0 {
+		return nil
+	}
+
+	row, col := app.cursorPosition()
+	row = clampInt(row+rowDelta, 0, rowCount-1)
+	col = clampInt(col+colDelta, 0, colCount-1)
+	app.setCursorPosition(row, col)
+	app.revealCursor()
+	return app.render()
+}
+
+func (app *App) revealCursor() {
+	row, col := app.cursorPosition()
+
+	viewportRows := max(1, app.scrollState.ViewportRows)
+	offset := app.currentOffset()
+	if row < offset {
+		offset = row
+	}
+	if row >= offset+viewportRows {
+		offset = row - viewportRows + 1
+	}
+	app.setCurrentOffset(offset)
+
+	tableView, messageView := app.buildTableView()
+	if messageView || col >= len(tableView.ColumnWidths) {
+		return
+	}
+
+	start := 0
+	for _, width := range tableView.ColumnWidths[:col] {
+		start += width + tableView.SeparatorWidth
+	}
+	end := start + tableView.ColumnWidths[col]
+
+	viewportWidth := max(1, app.scrollState.ViewportWidth)
+	if end > app.scrollX+viewportWidth {
+		app.scrollX = end - viewportWidth
+	}
+	if start < app.scrollX || end-start > viewportWidth {
+		app.scrollX = start
+	}
+}
+
+func (app *App) keepCursorInViewport() {
+	row, col := app.cursorPosition()
+	offset := app.currentOffset()
+	viewportRows := max(1, app.scrollState.ViewportRows)
+	app.setCursorPosition(clampInt(row, offset, offset+viewportRows-1), col)
+}
+
+func (app *App) clampCursor(rowCount int) {
+	if app.viewMode == viewText {
+		return
+	}
+
+	row, col := app.cursorPosition()
+	row = clampInt(row, 0, max(0, rowCount-1))
+	col = clampInt(col, 0, max(0, len(app.currentColumns())-1))
+	app.setCursorPosition(row, col)
+}
+
+func (app *App) cursorCellStyle(base func(rowIndex int, colIndex int) string) func(rowIndex int, colIndex int) string {
+	row, col := app.cursorPosition()
+	cursorIndex := row - app.cursorRowBase()
+
+	return func(rowIndex int, colIndex int) string {
+		style := ""
+		if base != nil {
+			style = base(rowIndex, colIndex)
+		}
+
+		if rowIndex == cursorIndex && colIndex == col {
+			style += tableformat.StyleCursor
+		}
+
+		return style
+	}
+}
+
+func (app *App) moveCursorToClick(view *gocui.View) bool {
+	if app.viewMode == viewText {
+		return false
+	}
+
+	cursorX, cursorY := view.Cursor()
+	originX, originY := view.Origin()
+	x := cursorX + originX
+	y := cursorY + originY
+
+	tableView, messageView := app.buildTableView()
+	if messageView || y < 0 || y >= tableView.RowCount {
+		return false
+	}
+
+	colIndex := hitTestColumn(tableView, x)
+	if colIndex < 0 {
+		return false
+	}
+
+	app.setCursorPosition(app.cursorRowBase()+y, colIndex)
+	return true
+}
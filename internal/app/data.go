@@ -1,25 +1,46 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
 	"strings"
+	"time"
+
+	"github.com/awesome-gocui/gocui"
 
 	"squlito/internal/db"
+	"squlito/internal/sqlsyntax"
 )
 
-func (app *App) setSelectedTable(index int) error {
-	if index < 0 || index >= len(app.tables) {
-		return nil
-	}
+func (app *App) selectTable(object db.SchemaObject) error {
+	return app.openTableLocation(tableLocation{
+		Object:    object,
+		Filter:    TableFilter{Where: ""},
+		Sort:      nil,
+		Offset:    0,
+		CursorRow: 0,
+		CursorCol: 0,
+		ScrollX:   app.scrollX,
+	})
+}
 
-	app.selectedTableIndex = index
-	tableName := app.tables[index].Name
-	app.tableState.Name = tableName
-	app.tableState.Offset = 0
-	app.tableState.BufferStart = 0
+func (app *App) openTableLocation(location tableLocation) error {
+	object := location.Object
+	app.tableState.Name = object.Name
+	app.tableState.ObjectType = object.Type
+	app.tableState.Filter = location.Filter
+	app.tableState.Sort = location.Sort
+	app.tableState.Offset = location.Offset
+	app.tableState.CursorRow = location.CursorRow
+	app.tableState.CursorCol = location.CursorCol
+	app.tableState.BufferStart = max(0, location.Offset-app.tableState.BufferSize/2)
+	app.scrollX = location.ScrollX
+	app.tableState.Stale = true
 	app.tableState.Error = ""
 	app.viewMode = viewTable
 	app.queryState.Error = ""
-	app.queryState.Running = false
 
 	err := app.reloadTableBuffer()
 	if err != nil {
@@ -34,21 +55,49 @@ func (app *App) reloadTableBuffer() error {
 		return nil
 	}
 
-	page, err := db.GetTablePage(app.db, app.tableState.Name, app.tableState.BufferSize, app.tableState.BufferStart)
+	if app.tableState.Stale {
+		err := app.loadTableMeta()
+		if err != nil {
+			app.failTableBuffer(err)
+			return err
+		}
+	}
+
+	page, err := db.QueryTablePage(app.db, app.tablePageQuery(app.tableState.BufferStart, app.tableState.BufferSize, app.tableState.Stale))
 	if err != nil {
-		app.tableState.Rows = nil
-		app.tableState.Columns = nil
-		app.tableState.TotalRows = 0
-		app.tableState.Error = err.Error()
+		app.failTableBuffer(err)
 		return err
 	}
 
+	app.tableState.TotalRows = page.TotalRows
+	app.tableState.BufferStart = page.Offset
+	app.tableState.Rows = page.Rows
+	app.tableState.Anchors = db.MergeAnchors(app.tableState.Anchors, page.Anchors, tableAnchorLimit)
+	app.tableState.Stale = false
+	app.tableState.Error = ""
+
+	return nil
+}
+
+func (app *App) tablePageQuery(offset int, limit int, countRows bool) db.TablePageQuery {
+	return db.TablePageQuery{
+		Table:        app.tableState.Name,
+		Limit:        limit,
+		Offset:       offset,
+		IncludeRowID: slices.Contains(app.tableState.KeyColumns, db.RowIDColumn),
+		KeyColumns:   app.tableState.PageKeyColumns,
+		Anchors:      app.tableState.Anchors,
+		Where:        app.tableState.Filter.Where,
+		WhereArgs:    nil,
+		OrderBy:      app.tableState.Sort,
+		CountRows:    countRows,
+		TotalRows:    app.tableState.TotalRows,
+	}
+}
+
+func (app *App) loadTableMeta() error {
 	cols, err := db.GetTableColumns(app.db, app.tableState.Name)
 	if err != nil {
-		app.tableState.Rows = nil
-		app.tableState.Columns = nil
-		app.tableState.TotalRows = 0
-		app.tableState.Error = err.Error()
 		return err
 	}
 
@@ -57,52 +106,520 @@ func (app *App) reloadTableBuffer() error {
 		columnNames = append(columnNames, col.Name)
 	}
 
-	app.tableState.TotalRows = page.TotalRows
-	app.tableState.BufferStart = page.Offset
-	app.tableState.Rows = page.Rows
-	app.tableState.Columns = columnNames
-	app.tableState.Error = ""
+	keyColumns := []string{}
+	if app.writable && app.tableState.ObjectType != db.ObjectView {
+		keyColumns, err = db.GetRowKeyColumns(app.db, app.tableState.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	pageKeyColumns, err := db.GetPageKeyColumns(app.db, app.tableState.Name)
+	if err != nil {
+		return err
+	}
 
+	app.tableState.Columns = columnNames
+	app.tableState.KeyColumns = keyColumns
+	app.tableState.PageKeyColumns = pageKeyColumns
+	app.tableState.Anchors = nil
 	return nil
 }
 
+func (app *App) failTableBuffer(err error) {
+	app.tableState.Rows = nil
+	app.tableState.Columns = nil
+	app.tableState.TotalRows = 0
+	app.tableState.Anchors = nil
+	app.tableState.Error = err.Error()
+}
+
 func (app *App) runQuery(sqlText string) error {
-	trimmed := strings.TrimSpace(sqlText)
-	app.viewMode = viewQuery
-	app.queryState.Offset = 0
+	parameters, err := sqlsyntax.Parameters(sqlText)
+	if err != nil {
+		app.rejectQuery(sqlText, err.Error())
+		return nil
+	}
+	if len(parameters) > 0 {
+		return app.promptQueryParameters(sqlText, parameters, map[string]string{})
+	}
+
+	return app.startQuery(sqlText, nil)
+}
 
+func (app *App) startQuery(sqlText string, bindings map[string]string) error {
+	tab := app.currentQueryTab()
+	state := &tab.State
+	trimmed := strings.TrimSpace(sqlText)
 	if trimmed == "" {
-		app.queryState.SQL = ""
-		app.queryState.AllRows = nil
-		app.queryState.Columns = nil
-		app.queryState.Error = "Query is empty"
-		app.queryState.Running = false
-		app.queryState.Truncated = false
+		app.rejectQuery("", "Query is empty")
 		return nil
 	}
 
+	app.viewMode = viewQuery
+	state.Offset = 0
+	state.CursorRow = 0
+	state.CursorCol = 0
+	discardQueryRows(tab)
+
 	app.resetHistorySelection()
-	app.recordHistory(trimmed)
+	app.recordHistory(tab, trimmed, bindings)
+	startQueryState(state, trimmed)
+	if len(bindings) > 0 {
+		parameters, _ := sqlsyntax.Parameters(trimmed)
+		state.Args = db.BindParameters(parameters, bindings)
+	}
 
-	app.queryState.SQL = trimmed
-	app.queryState.Running = true
-	app.queryState.Error = ""
-	app.queryState.Truncated = false
+	ctx, cancel := context.WithCancel(context.Background())
+	tab.cancel = cancel
+	runID := tab.runID
+	dbConn := app.db
+	args := state.Args
+
+	app.startSpinner()
+	go func() {
+		cursor, err := db.OpenQueryCursor(ctx, dbConn, trimmed, args...)
+		rows := []db.SqliteRow{}
+		if err == nil {
+			rows, err = cursor.Fetch(queryFetchSize)
+		}
+
+		app.gui.Update(func(gui *gocui.Gui) error {
+			app.finishQuery(tab, runID, cursor, rows, err)
+			return app.render()
+		})
+	}()
+
+	return nil
+}
+
+func (app *App) rejectQuery(sqlText string, message string) {
+	tab := app.currentQueryTab()
+	state := &tab.State
+	app.viewMode = viewQuery
+	state.Offset = 0
+	state.CursorRow = 0
+	state.CursorCol = 0
+	discardQueryRows(tab)
+
+	state.SQL = strings.TrimSpace(sqlText)
+	state.Args = nil
+	state.AllRows = nil
+	state.Columns = nil
+	state.Error = message
+	state.Running = false
+	state.Truncated = false
+	state.Script = nil
+}
+
+func startQueryState(state *QueryState, sqlText string) {
+	state.SQL = sqlText
+	state.Args = nil
+	state.AllRows = nil
+	state.Columns = nil
+	state.Running = true
+	state.Fetching = false
+	state.Error = ""
+	state.Truncated = false
+	state.Done = false
+	state.WindowStart = 0
+	state.RowCount = 0
+	state.StartedAt = time.Now()
+	state.Duration = 0
+	state.Script = nil
+}
+
+func (app *App) finishQuery(tab *QueryTab, runID int, cursor *db.QueryCursor, rows []db.SqliteRow, err error) {
+	if runID != tab.runID {
+		closeCursor(cursor)
+		return
+	}
+
+	state := &tab.State
+	state.Running = false
+	state.Duration = time.Since(state.StartedAt)
+	app.stopSpinnerIfIdle()
+	defer app.recordHistoryResult(tab)
+
+	if sqlsyntax.WritesData(state.SQL) {
+		app.tableState.Stale = true
+	}
 
-	result, err := db.QueryRows(app.db, trimmed, queryRowCap)
 	if err != nil {
-		app.queryState.AllRows = nil
-		app.queryState.Columns = nil
-		app.queryState.Error = err.Error()
-		app.queryState.Running = false
-		return err
+		closeCursor(cursor)
+		closeQueryStream(tab)
+		state.AllRows = nil
+		state.Columns = nil
+		state.Error = describeQueryError(err)
+		return
 	}
 
-	app.queryState.AllRows = result.Rows
-	app.queryState.Columns = result.Columns
-	app.queryState.Truncated = result.Truncated
-	app.queryState.Running = false
-	app.queryState.Error = ""
+	tab.cursor = cursor
+	state.Columns = cursor.Columns()
+	state.AllRows = rows
+	state.WindowStart = 0
+	state.RowCount = len(rows)
+	state.Error = ""
+	finishCursorIfDone(tab)
+}
 
-	return nil
+func (app *App) ensureQueryWindow(viewportRows int) {
+	tab := app.currentQueryTab()
+	state := tab.State
+	if state.Running || state.Fetching || state.Error != "" || state.SQL == "" {
+		return
+	}
+
+	windowEnd := state.WindowStart + len(state.AllRows)
+	if state.Offset < state.WindowStart || state.Offset+viewportRows > windowEnd && windowEnd < tab.spilled {
+		app.reloadQueryWindow(tab, max(0, state.Offset-queryFetchSize/2))
+		return
+	}
+
+	if tab.cursor == nil || windowEnd < state.RowCount {
+		return
+	}
+
+	if windowEnd-(state.Offset+viewportRows) < queryPrefetchMargin {
+		app.fetchMoreQueryRows(tab)
+	}
+}
+
+// queryFetch is what a background fetch needs from its tab; the batch it
+// produces lands at position, the tab's row count when the fetch started.
+type queryFetch struct {
+	tab       *QueryTab
+	cursor    *db.QueryCursor
+	runID     int
+	spill     *db.RowSpill
+	columns   []string
+	seedStart int
+	seed      []db.SqliteRow
+	position  int
+}
+
+type queryBatch struct {
+	rows    []db.SqliteRow
+	err     error
+	opened  *db.RowSpill
+	spilled int
+}
+
+func (app *App) fetchMoreQueryRows(tab *QueryTab) {
+	fetch := beginQueryFetch(tab)
+
+	go func() {
+		batch := fetch.run()
+		app.gui.Update(func(gui *gocui.Gui) error {
+			app.appendQueryRows(fetch, batch)
+			return app.render()
+		})
+	}()
+}
+
+func beginQueryFetch(tab *QueryTab) queryFetch {
+	state := &tab.State
+	var seed []db.SqliteRow
+	if tab.spill == nil && len(state.AllRows)+queryFetchSize > queryWindowSize {
+		seed = slices.Clone(state.AllRows)
+	}
+	state.Fetching = true
+
+	return queryFetch{
+		tab:       tab,
+		cursor:    tab.cursor,
+		runID:     tab.runID,
+		spill:     tab.spill,
+		columns:   state.Columns,
+		seedStart: state.WindowStart,
+		seed:      seed,
+		position:  state.RowCount,
+	}
+}
+
+// run fetches the batch and writes it to the tab's on-disk cache, opening the
+// cache with the rows already in memory once the window is about to overflow.
+func (fetch queryFetch) run() queryBatch {
+	rows, err := fetch.cursor.Fetch(queryFetchSize)
+	batch := queryBatch{rows: rows, err: err, opened: nil, spilled: 0}
+	if err != nil {
+		return batch
+	}
+
+	spill := fetch.spill
+	position := fetch.position
+	written := rows
+	if spill == nil {
+		if fetch.seed == nil {
+			return batch
+		}
+
+		batch.opened, batch.err = db.OpenRowSpill(fetch.columns)
+		if batch.err != nil {
+			batch.err = fmt.Errorf("caching rows on disk: %w", batch.err)
+			return batch
+		}
+		spill = batch.opened
+		written = append(fetch.seed, rows...)
+		position = fetch.seedStart
+	}
+
+	err = spill.Write(position, written)
+	if err != nil {
+		_ = batch.opened.Close()
+		batch.opened = nil
+		batch.err = fmt.Errorf("caching rows on disk: %w", err)
+		return batch
+	}
+
+	batch.spilled = position + len(written)
+	return batch
+}
+
+// appendQueryRows adds a fetched batch to the window. Every row in [0, spilled)
+// is also in the tab's on-disk cache, so evicting it only costs a re-read.
+func (app *App) appendQueryRows(fetch queryFetch, batch queryBatch) {
+	tab := fetch.tab
+	if fetch.runID != tab.runID {
+		closeCursor(fetch.cursor)
+		_ = batch.opened.Close()
+		app.resumeSearch(tab)
+		return
+	}
+
+	state := &tab.State
+	state.Fetching = false
+	if batch.err != nil {
+		closeQueryStream(tab)
+		state.Truncated = true
+		app.setStatusMessage("Fetching rows failed: " + describeQueryError(batch.err))
+		app.recordHistoryResult(tab)
+		app.resumeSearch(tab)
+		return
+	}
+
+	if batch.opened != nil {
+		tab.spill = batch.opened
+	}
+	if tab.spill != nil {
+		tab.spilled = batch.spilled
+	}
+
+	if fetch.position == state.WindowStart+len(state.AllRows) {
+		state.AllRows = append(state.AllRows, batch.rows...)
+		state.RowCount = max(state.RowCount, state.WindowStart+len(state.AllRows))
+		evictQueryRows(state, tab.spilled)
+	} else {
+		// The window was scrolled back into the cache, so the batch only
+		// goes there.
+		state.RowCount = tab.spilled
+	}
+	finishCursorIfDone(tab)
+	if state.Done {
+		app.recordHistoryResult(tab)
+	}
+	app.resumeSearch(tab)
+}
+
+func (app *App) reloadQueryWindow(tab *QueryTab, start int) {
+	spill := tab.spill
+	if spill == nil {
+		return
+	}
+
+	tab.State.Fetching = true
+	runID := tab.runID
+	limit := min(queryFetchSize*2, tab.spilled-start)
+
+	go func() {
+		rows, err := spill.Read(start, limit)
+		app.gui.Update(func(gui *gocui.Gui) error {
+			app.replaceQueryWindow(tab, runID, start, rows, err)
+			return app.render()
+		})
+	}()
+}
+
+func (app *App) replaceQueryWindow(tab *QueryTab, runID int, start int, rows []db.SqliteRow, err error) {
+	if runID != tab.runID {
+		app.resumeSearch(tab)
+		return
+	}
+
+	state := &tab.State
+	state.Fetching = false
+	if err != nil {
+		app.setStatusMessage("Reading cached rows failed: " + err.Error())
+	} else {
+		state.AllRows = rows
+		state.WindowStart = start
+	}
+	app.resumeSearch(tab)
+}
+
+func evictQueryRows(state *QueryState, spilled int) {
+	excess := len(state.AllRows) - queryWindowSize
+	if excess <= 0 {
+		return
+	}
+
+	behindOffset := state.Offset - state.WindowStart - queryPrefetchMargin
+	drop := min(excess, max(0, behindOffset), max(0, spilled-state.WindowStart))
+	keep := len(state.AllRows) - drop
+	if state.WindowStart+len(state.AllRows) <= spilled {
+		// Fetching ahead of the viewport, e.g. for a search: the tail is
+		// cached too, so trim it rather than the rows on screen.
+		keep = queryWindowSize
+	}
+	if drop == 0 && keep == len(state.AllRows) {
+		return
+	}
+
+	state.AllRows = slices.Clone(state.AllRows[drop : drop+keep])
+	state.WindowStart += drop
+}
+
+func finishCursorIfDone(tab *QueryTab) {
+	if tab.cursor == nil || !tab.cursor.Done() {
+		return
+	}
+
+	tab.State.Done = true
+	tab.State.RowCount = tab.cursor.Position()
+	closeQueryStream(tab)
+}
+
+func (app *App) cancelQuery() bool {
+	tab := app.currentQueryTab()
+	state := &tab.State
+	if !state.Running && !state.Fetching {
+		return false
+	}
+
+	running := state.Running
+	closeQueryStream(tab)
+	state.Running = false
+	state.Fetching = false
+	app.stopSpinnerIfIdle()
+
+	if !running {
+		state.Truncated = true
+		app.setStatusMessage("Stopped fetching rows")
+		app.recordHistoryResult(tab)
+		return true
+	}
+
+	state.Duration = time.Since(state.StartedAt)
+	state.AllRows = nil
+	state.Columns = nil
+	state.Error = "Query cancelled"
+	app.recordHistoryResult(tab)
+	return true
+}
+
+func (app *App) stopQueryStreams() {
+	for _, tab := range app.queryTabs {
+		state := &tab.State
+		if state.Running {
+			closeQueryStream(tab)
+			state.Duration = time.Since(state.StartedAt)
+			state.AllRows = nil
+			state.Columns = nil
+			state.Error = "Query cancelled"
+			app.recordHistoryResult(tab)
+			continue
+		}
+
+		if state.Fetching || tab.cursor != nil {
+			closeQueryStream(tab)
+			state.Truncated = true
+			app.recordHistoryResult(tab)
+		}
+	}
+
+	app.stopSpinnerIfIdle()
+}
+
+func discardQueryRows(tab *QueryTab) {
+	closeQueryStream(tab)
+	_ = tab.spill.Close()
+	tab.spill = nil
+	tab.spilled = 0
+}
+
+func closeQueryStream(tab *QueryTab) {
+	inFlight := tab.State.Running || tab.State.Fetching
+	if inFlight {
+		tab.runID += 1
+		tab.State.Running = false
+		tab.State.Fetching = false
+	}
+
+	if tab.cancel != nil {
+		tab.cancel()
+		tab.cancel = nil
+	}
+
+	if !inFlight {
+		closeCursor(tab.cursor)
+	}
+	tab.cursor = nil
+}
+
+func (app *App) startSpinner() {
+	app.stopSpinner()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	app.spinnerCancel = cancel
+
+	go func() {
+		ticker := time.NewTicker(spinnerInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				app.gui.Update(func(gui *gocui.Gui) error {
+					return app.render()
+				})
+			}
+		}
+	}()
+}
+
+func (app *App) stopSpinnerIfIdle() {
+	for _, tab := range app.queryTabs {
+		if tab.State.Running {
+			return
+		}
+	}
+
+	app.stopSpinner()
+}
+
+func (app *App) stopSpinner() {
+	if app.spinnerCancel == nil {
+		return
+	}
+
+	app.spinnerCancel()
+	app.spinnerCancel = nil
+}
+
+func closeCursor(cursor *db.QueryCursor) {
+	if cursor == nil {
+		return
+	}
+
+	_ = cursor.Close()
+}
+
+func describeQueryError(err error) string {
+	if errors.Is(err, context.Canceled) || strings.Contains(err.Error(), "interrupted") {
+		return "Query cancelled"
+	}
+
+	return err.Error()
 }
@@ -0,0 +1,232 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/awesome-gocui/gocui"
+
+	"squlito/internal/db"
+	"squlito/internal/export"
+	"squlito/internal/sqlsyntax"
+	"squlito/internal/tableformat"
+)
+
+type exportSource struct {
+	Name  string
+	SQL   string
+	Args  []any
+	Total int
+}
+
+func (app *App) openExport() error {
+	if app.exportState.Running {
+		app.setStatusMessage("An export is already running: Esc cancels it")
+		return nil
+	}
+
+	source, ok := app.currentExportSource()
+	if !ok {
+		return nil
+	}
+
+	return app.openPrompt("Export to (.csv .tsv .json .ndjson .md .sql)", source.Name+".csv", func(value string) error {
+		path := strings.TrimSpace(value)
+		format, ok := export.FormatFromPath(path)
+		if !ok {
+			app.setStatusMessage("Unknown export format: use .csv, .tsv, .json, .ndjson, .md or .sql")
+			return nil
+		}
+
+		_, err := os.Stat(path)
+		if err != nil {
+			app.startExport(source, path, format)
+			return nil
+		}
+
+		return app.openPrompt(path+" exists: overwrite it? (y/n)", "n", func(answer string) error {
+			switch strings.ToLower(strings.TrimSpace(answer)) {
+			case "y", "yes":
+				app.startExport(source, path, format)
+			default:
+				app.setStatusMessage("Export cancelled; " + path + " was left unchanged")
+			}
+			return nil
+		})
+	})
+}
+
+func (app *App) currentExportSource() (source exportSource, ok bool) {
+	switch app.viewMode {
+	case viewTable:
+		if app.tableState.Name == "" {
+			app.setStatusMessage("Open a table to export it")
+			return source, false
+		}
+
+		sqlText, args := db.TableSelectSQL(app.tableState.Name, app.tableState.Filter.Where, nil, app.tableState.Sort, app.tableState.PageKeyColumns)
+		return exportSource{Name: app.tableState.Name, SQL: sqlText, Args: args, Total: app.tableState.TotalRows}, true
+	case viewQuery:
+		if len(app.queryState.Columns) == 0 || !sqlsyntax.ReturnsRows(app.queryState.SQL) {
+			app.setStatusMessage("Only query results that return rows can be exported")
+			return source, false
+		}
+		if sqlsyntax.WritesData(app.queryState.SQL) {
+			app.setStatusMessage("Exporting would run the INSERT, UPDATE or DELETE again: select the rows instead")
+			return source, false
+		}
+
+		total := 0
+		if app.queryState.Done {
+			total = app.queryState.RowCount
+		}
+		return exportSource{Name: export.ResultTableName, SQL: app.queryState.SQL, Args: app.queryState.Args, Total: total}, true
+	default:
+		app.setStatusMessage("Export is only available for tables and query results")
+		return source, false
+	}
+}
+
+func (app *App) startExport(source exportSource, path string, format export.Format) {
+	ctx, cancel := context.WithCancel(context.Background())
+	app.exportCancel = cancel
+	app.exportState = ExportState{
+		Running:   true,
+		Path:      path,
+		Rows:      0,
+		Total:     source.Total,
+		StartedAt: time.Now(),
+	}
+	dbConn := app.db
+
+	go func() {
+		progress := func(rows int) {
+			app.gui.Update(func(gui *gocui.Gui) error {
+				app.exportState.Rows = rows
+				return app.render()
+			})
+		}
+
+		rows, err := writeExportFile(ctx, dbConn, source, format, path, progress)
+		if err != nil && ctx.Err() != nil {
+			err = ctx.Err()
+		}
+
+		app.gui.Update(func(gui *gocui.Gui) error {
+			app.finishExport(rows, err)
+			return app.render()
+		})
+	}()
+}
+
+func (app *App) finishExport(rows int, err error) {
+	state := app.exportState
+	app.exportState.Running = false
+	app.exportState.Rows = rows
+	app.exportCancel = nil
+
+	if errors.Is(err, context.Canceled) {
+		app.setStatusMessage("Export cancelled")
+		return
+	}
+
+	if err != nil {
+		app.setStatusMessage("Export failed: " + describeQueryError(err))
+		return
+	}
+
+	app.setStatusMessage(fmt.Sprintf("Exported %d rows to %s in %s", rows, state.Path, formatDuration(time.Since(state.StartedAt))))
+}
+
+func (app *App) cancelExport() bool {
+	if !app.exportState.Running || app.exportCancel == nil {
+		return false
+	}
+
+	app.exportCancel()
+	app.exportCancel = nil
+	return true
+}
+
+func (app *App) exportProgress() string {
+	state := app.exportState
+	if state.Total <= 0 {
+		return fmt.Sprintf("Exporting to %s: %d rows", state.Path, state.Rows)
+	}
+
+	percent := min(100, state.Rows*100/state.Total)
+	bar := tableformat.ProgressBar(int64(state.Rows), int64(state.Total), progressBarWidth)
+	return fmt.Sprintf("Exporting to %s: %d/%d rows %s %d%%", state.Path, state.Rows, state.Total, bar, percent)
+}
+
+func writeExportFile(ctx context.Context, dbConn *sql.DB, source exportSource, format export.Format, path string, progress func(rows int)) (count int, err error) {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		closeErr := file.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Rename(file.Name(), path)
+		}
+		if err != nil {
+			_ = os.Remove(file.Name())
+		}
+	}()
+
+	mode := os.FileMode(0o644)
+	info, err := os.Stat(path)
+	if err == nil {
+		mode = info.Mode().Perm()
+	}
+	err = file.Chmod(mode)
+	if err != nil {
+		return 0, err
+	}
+
+	cursor, err := db.OpenQueryCursor(ctx, dbConn, source.SQL, source.Args...)
+	if err != nil {
+		return 0, err
+	}
+	defer closeCursor(cursor)
+
+	buffered := bufio.NewWriter(file)
+	writer, err := export.NewWriter(buffered, format, source.Name, cursor.Columns())
+	if err != nil {
+		return 0, err
+	}
+
+	for !cursor.Done() {
+		rows, fetchErr := cursor.Fetch(exportBatchSize)
+		if fetchErr != nil {
+			return count, fetchErr
+		}
+
+		for _, row := range rows {
+			err = writer.WriteRow(row)
+			if err != nil {
+				return count, err
+			}
+		}
+
+		count += len(rows)
+		progress(count)
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return count, err
+	}
+
+	return count, buffered.Flush()
+}
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"strings"
+
+	"squlito/internal/db"
+)
+
+func (app *App) requireTableView(action string) bool {
+	if app.viewMode != viewTable || app.tableState.Name == "" {
+		app.setStatusMessage(action + " is only available when browsing a table")
+		return false
+	}
+
+	return true
+}
+
+func (app *App) editFilter() error {
+	if !app.requireTableView("Filtering") {
+		return nil
+	}
+
+	return app.openPrompt("Filter: WHERE ...", app.tableState.Filter.Where, func(value string) error {
+		app.applyFilter(strings.TrimSpace(value))
+		return nil
+	})
+}
+
+func (app *App) addCellFilter() {
+	if !app.requireTableView("Filtering") {
+		return
+	}
+
+	cell, ok := app.cellAtCursor()
+	if !ok {
+		return
+	}
+
+	condition := db.LiteralCondition(db.RowKey{Columns: []string{cell.Column}, Values: []db.SqliteValue{cell.Value}})
+	app.applyFilter(combineConditions(app.tableState.Filter.Where, condition))
+}
+
+func (app *App) clearFilter() {
+	if app.tableState.Filter.Where == "" {
+		return
+	}
+
+	app.applyFilter("")
+}
+
+func (app *App) applyFilter(where string) {
+	previous := app.tableState.Filter
+	err := app.setTableFilter(TableFilter{Where: where})
+	if err == nil {
+		return
+	}
+
+	_ = app.setTableFilter(previous)
+	app.setStatusMessage("Invalid filter: " + err.Error())
+}
+
+func (app *App) setTableFilter(filter TableFilter) error {
+	app.tableState.Filter = filter
+	app.tableState.Offset = 0
+	app.tableState.BufferStart = 0
+	app.tableState.CursorRow = 0
+	app.tableState.Stale = true
+	return app.reloadTableBuffer()
+}
+
+func combineConditions(existing string, condition string) string {
+	existing = strings.TrimSpace(existing)
+	if existing == "" {
+		return condition
+	}
+
+	return "(" + existing + ") AND (" + condition + ")"
+}
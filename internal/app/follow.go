@@ -0,0 +1,190 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"squlito/internal/db"
+)
+
+const tableHistoryLimit = 50
+
+func (app *App) followForeignKey() error {
+	if app.viewMode != viewTable {
+		return nil
+	}
+
+	cell, ok := app.cellAtCursor()
+	if !ok {
+		return nil
+	}
+
+	foreignKeys, err := db.GetForeignKeys(app.db, app.tableState.Name)
+	if err != nil {
+		app.setStatusMessage("Loading foreign keys failed: " + err.Error())
+		return nil
+	}
+
+	index := slices.IndexFunc(foreignKeys, func(foreignKey db.ForeignKey) bool {
+		return slices.Contains(foreignKey.From, cell.Column)
+	})
+	if index < 0 {
+		app.setStatusMessage(cell.Column + " is not a foreign key")
+		return nil
+	}
+
+	foreignKey := foreignKeys[index]
+	row := app.displayTableRows()[cell.RowIndex]
+	values := []db.SqliteValue{}
+	for _, column := range foreignKey.From {
+		value := row[column]
+		if value == nil {
+			app.setStatusMessage(column + " is NULL")
+			return nil
+		}
+		values = append(values, value)
+	}
+
+	if slices.Contains(foreignKey.To, "") {
+		app.setStatusMessage(foreignKey.Table + " has no key matching " + cell.Column)
+		return nil
+	}
+
+	return app.openFilteredTable(foreignKey.Table, db.RowKey{Columns: foreignKey.To, Values: values})
+}
+
+func (app *App) showInboundReferences() error {
+	if app.viewMode != viewTable {
+		return nil
+	}
+
+	cell, ok := app.cellAtCursor()
+	if !ok {
+		return nil
+	}
+
+	references, err := db.ListInboundReferences(app.db, app.tableState.Name)
+	if err != nil {
+		app.setStatusMessage("Loading references failed: " + err.Error())
+		return nil
+	}
+
+	row := app.displayTableRows()[cell.RowIndex]
+	items := []pickerItem{}
+	for _, reference := range references {
+		values := []db.SqliteValue{}
+		for _, column := range reference.ForeignKey.To {
+			value, ok := row[column]
+			if !ok || value == nil {
+				break
+			}
+			values = append(values, value)
+		}
+		if len(values) != len(reference.ForeignKey.From) {
+			continue
+		}
+
+		key := db.RowKey{Columns: reference.ForeignKey.From, Values: values}
+		where, args, err := db.KeyFilter(key)
+		if err != nil {
+			return err
+		}
+
+		count, err := db.CountFilteredRows(app.db, reference.Table, where, args)
+		if err != nil {
+			app.setStatusMessage("Counting references failed: " + err.Error())
+			return nil
+		}
+
+		table := reference.Table
+		items = append(items, pickerItem{
+			Label:    fmt.Sprintf("%s.%s (%d)", table, strings.Join(key.Columns, ", "), count),
+			OnSelect: func() error { return app.openFilteredTable(table, key) },
+			OnRun:    nil,
+		})
+	}
+
+	if len(items) == 0 {
+		app.setStatusMessage("No tables reference this row")
+		return nil
+	}
+
+	return app.openPicker("Referenced by", items)
+}
+
+func (app *App) openFilteredTable(tableName string, key db.RowKey) error {
+	target, ok := app.schemaObject(tableName)
+	if !ok {
+		app.setStatusMessage("Table " + tableName + " not found")
+		return nil
+	}
+
+	where := db.LiteralCondition(key)
+	app.pushTableHistory()
+	err := app.openTableLocation(tableLocation{
+		Object:    target,
+		Filter:    TableFilter{Where: where},
+		Sort:      nil,
+		Offset:    0,
+		CursorRow: 0,
+		CursorCol: 0,
+		ScrollX:   0,
+	})
+	if err != nil {
+		return nil
+	}
+
+	app.revealSidebarObject(target.Name)
+	return nil
+}
+
+func (app *App) goBack() {
+	if len(app.tableHistory) == 0 {
+		app.setStatusMessage("Nothing to go back to")
+		return
+	}
+
+	location := app.tableHistory[len(app.tableHistory)-1]
+	app.tableHistory = app.tableHistory[:len(app.tableHistory)-1]
+
+	err := app.openTableLocation(location)
+	if err != nil {
+		return
+	}
+
+	app.revealSidebarObject(location.Object.Name)
+}
+
+func (app *App) pushTableHistory() {
+	object, _ := app.schemaObject(app.tableState.Name)
+	app.tableHistory = append(app.tableHistory, tableLocation{
+		Object:    object,
+		Filter:    app.tableState.Filter,
+		Sort:      app.tableState.Sort,
+		Offset:    app.tableState.Offset,
+		CursorRow: app.tableState.CursorRow,
+		CursorCol: app.tableState.CursorCol,
+		ScrollX:   app.scrollX,
+	})
+
+	if len(app.tableHistory) > tableHistoryLimit {
+		app.tableHistory = slices.Delete(app.tableHistory, 0, len(app.tableHistory)-tableHistoryLimit)
+	}
+}
+
+func (app *App) schemaObject(name string) (object db.SchemaObject, ok bool) {
+	for _, candidate := range app.schema {
+		if candidate.Name == name && isBrowsableObject(candidate) {
+			return candidate, true
+		}
+	}
+
+	for _, candidate := range app.schema {
+		if strings.EqualFold(candidate.Name, name) && isBrowsableObject(candidate) {
+			return candidate, true
+		}
+	}
+
+	return object, false
+}
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"fmt"
 	"log/slog"
 	"time"
 
@@ -34,6 +35,24 @@ func (app *App) bindKeys() error {
 	if err := gui.SetKeybinding("", gocui.KeyCtrlL, gocui.ModNone, app.handlePaneRight); err != nil {
 		return err
 	}
+	if err := gui.SetKeybinding("", gocui.KeyCtrlT, gocui.ModNone, app.handleNewQueryTab); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("", gocui.KeyCtrlW, gocui.ModNone, app.handleCloseQueryTab); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("", gocui.KeyCtrlN, gocui.ModNone, app.handleNextQueryTab); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("", gocui.KeyCtrlP, gocui.ModNone, app.handlePrevQueryTab); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("", gocui.KeyCtrlR, gocui.ModNone, app.handleOpenHistory); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("", gocui.KeyCtrlO, gocui.ModNone, app.handleOpenSnippets); err != nil {
+		return err
+	}
 
 	if err := gui.SetKeybinding("sidebar", gocui.KeyArrowDown, gocui.ModNone, app.handleSidebarDown); err != nil {
 		return err
@@ -50,6 +69,21 @@ func (app *App) bindKeys() error {
 	if err := gui.SetKeybinding("sidebar", gocui.KeyEnter, gocui.ModNone, app.handleSidebarEnter); err != nil {
 		return err
 	}
+	if err := gui.SetKeybinding("sidebar", gocui.KeySpace, gocui.ModNone, app.handleSidebarToggle); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("sidebar", 'l', gocui.ModNone, app.handleSidebarExpand); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("sidebar", gocui.KeyArrowRight, gocui.ModNone, app.handleSidebarExpand); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("sidebar", 'h', gocui.ModNone, app.handleSidebarCollapse); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("sidebar", gocui.KeyArrowLeft, gocui.ModNone, app.handleSidebarCollapse); err != nil {
+		return err
+	}
 
 	if err := gui.SetKeybinding("rowsBody", gocui.KeyArrowDown, gocui.ModNone, app.handleRowsDown); err != nil {
 		return err
@@ -76,15 +110,94 @@ func (app *App) bindKeys() error {
 		return err
 	}
 
+	if err := gui.SetKeybinding("rowsBody", gocui.KeyEnter, gocui.ModNone, app.handleRowsEnter); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("rowsBody", 's', gocui.ModNone, app.handleSort); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("rowsBody", 'S', gocui.ModNone, app.handleSortAdd); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("rowsBody", 'f', gocui.ModNone, app.handleEditFilter); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("rowsBody", '=', gocui.ModNone, app.handleAddCellFilter); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("rowsBody", 'F', gocui.ModNone, app.handleClearFilter); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("rowsBody", '/', gocui.ModNone, app.handleSearch); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("rowsBody", 'n', gocui.ModNone, app.handleSearchNext); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("rowsBody", 'N', gocui.ModNone, app.handleSearchPrev); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("rowsBody", 'i', gocui.ModNone, app.handleToggleStructure); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("sidebar", 'i', gocui.ModNone, app.handleToggleStructure); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("rowsBody", 'g', gocui.ModNone, app.handleFollowForeignKey); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("rowsBody", 'b', gocui.ModNone, app.handleGoBack); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("rowsBody", 'r', gocui.ModNone, app.handleShowReferences); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("rowsBody", 'E', gocui.ModNone, app.handleExport); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("rowsBody", 'I', gocui.ModNone, app.handleImport); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("sidebar", 'I', gocui.ModNone, app.handleImport); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("rowsBody", 'e', gocui.ModNone, app.handleRowsEdit); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("rowsBody", 'o', gocui.ModNone, app.handleRowsInsert); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("rowsBody", 'd', gocui.ModNone, app.handleRowsDelete); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("rowsBody", 'w', gocui.ModNone, app.handleCommitChanges); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("rowsBody", 'U', gocui.ModNone, app.handleDiscardChanges); err != nil {
+		return err
+	}
+
 	if err := gui.SetKeybinding("query", gocui.KeyEnter, gocui.ModNone, app.handleQuerySubmit); err != nil {
 		return err
 	}
 	if err := gui.SetKeybinding("query", gocui.KeyEnter, gocui.ModShift, app.handleQueryNewline); err != nil {
 		return err
 	}
+	if err := gui.SetKeybinding("query", gocui.KeyCtrlE, gocui.ModNone, app.handleQueryRunAll); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("query", gocui.KeyCtrlS, gocui.ModNone, app.handleSaveSnippet); err != nil {
+		return err
+	}
 	if err := gui.SetKeybinding("query", gocui.KeyCtrlJ, gocui.ModNone, app.handleQueryNewline); err != nil {
 		return err
 	}
+	if err := gui.SetKeybinding("query", gocui.KeyTab, gocui.ModNone, app.handleQueryComplete); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("query", gocui.KeyCtrlSpace, gocui.ModNone, app.handleQueryCompleteAll); err != nil {
+		return err
+	}
 	if err := gui.SetKeybinding("query", gocui.KeyArrowUp, gocui.ModNone, app.handleQueryHistoryPrev); err != nil {
 		return err
 	}
@@ -98,6 +211,9 @@ func (app *App) bindKeys() error {
 	if err := gui.SetKeybinding("rowsBody", gocui.MouseLeft, gocui.ModNone, app.handleRowsClick); err != nil {
 		return err
 	}
+	if err := gui.SetKeybinding("rowsHeader", gocui.MouseLeft, gocui.ModNone, app.handleRowsHeaderClick); err != nil {
+		return err
+	}
 	if err := gui.SetKeybinding("query", gocui.MouseLeft, gocui.ModNone, app.handleQueryClick); err != nil {
 		return err
 	}
@@ -111,7 +227,7 @@ func (app *App) bindKeys() error {
 	if err := gui.SetKeybinding(modalViewName, gocui.KeyEsc, gocui.ModNone, app.handleModalClose); err != nil {
 		return err
 	}
-	if err := gui.SetKeybinding(modalViewName, gocui.KeyEnter, gocui.ModNone, app.handleModalClose); err != nil {
+	if err := gui.SetKeybinding(modalViewName, gocui.KeyEnter, gocui.ModNone, app.handleModalEnter); err != nil {
 		return err
 	}
 	if err := gui.SetKeybinding(modalViewName, 'q', gocui.ModNone, app.handleModalClose); err != nil {
@@ -129,12 +245,65 @@ func (app *App) bindKeys() error {
 	if err := gui.SetKeybinding(modalViewName, 'k', gocui.ModNone, app.handleModalUp); err != nil {
 		return err
 	}
+	if err := gui.SetKeybinding(modalViewName, 'r', gocui.ModNone, app.handleModalRun); err != nil {
+		return err
+	}
+
+	if err := gui.SetKeybinding(historySearchViewName, gocui.KeyEnter, gocui.ModNone, app.handleHistoryLoad); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(historySearchViewName, gocui.KeyEsc, gocui.ModNone, app.handleHistoryClose); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(historySearchViewName, gocui.KeyCtrlR, gocui.ModNone, app.handleHistoryClose); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(historySearchViewName, gocui.KeyArrowDown, gocui.ModNone, app.handleHistoryDown); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(historySearchViewName, gocui.KeyCtrlN, gocui.ModNone, app.handleHistoryDown); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(historySearchViewName, gocui.KeyCtrlJ, gocui.ModNone, app.handleHistoryDown); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(historySearchViewName, gocui.KeyTab, gocui.ModNone, app.handleHistoryDown); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(historySearchViewName, gocui.KeyArrowUp, gocui.ModNone, app.handleHistoryUp); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(historySearchViewName, gocui.KeyCtrlP, gocui.ModNone, app.handleHistoryUp); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(historySearchViewName, gocui.KeyCtrlK, gocui.ModNone, app.handleHistoryUp); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(historySearchViewName, gocui.KeyCtrlD, gocui.ModNone, app.handleHistoryDelete); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(historySearchViewName, gocui.KeyCtrlA, gocui.ModNone, app.handleHistoryScope); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(historySearchViewName, gocui.KeyCtrlF, gocui.ModNone, app.handleHistoryPin); err != nil {
+		return err
+	}
+
+	if err := gui.SetKeybinding(promptViewName, gocui.KeyEnter, gocui.ModNone, app.handlePromptSubmit); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(promptViewName, gocui.KeyEsc, gocui.ModNone, app.handlePromptCancel); err != nil {
+		return err
+	}
 
 	return nil
 }
 
 func (app *App) setFocus(area FocusArea) error {
 	app.focusArea = area
+	if area != focusQuery {
+		app.closeCompletion()
+	}
 
 	var viewName string
 	if area == focusSidebar {
@@ -149,6 +318,12 @@ func (app *App) setFocus(area FocusArea) error {
 	if area == focusModal {
 		viewName = modalViewName
 	}
+	if area == focusPrompt {
+		viewName = promptViewName
+	}
+	if area == focusHistory {
+		viewName = historySearchViewName
+	}
 
 	if viewName != "" {
 		_, err := app.gui.SetCurrentView(viewName)
@@ -157,15 +332,77 @@ func (app *App) setFocus(area FocusArea) error {
 		}
 	}
 
-	app.gui.Cursor = area == focusQuery
+	app.gui.Cursor = area == focusQuery || area == focusPrompt || area == focusHistory
 	return nil
 }
 
 func (app *App) quit(gui *gocui.Gui, view *gocui.View) error {
 	logEvent("quit")
+	if len(app.pendingChanges) > 0 && !app.quitArmed {
+		app.quitArmed = true
+		app.setStatusMessage(fmt.Sprintf("%d pending changes: w to commit, q again to quit without saving", len(app.pendingChanges)))
+		return app.render()
+	}
+
 	return gocui.ErrQuit
 }
 
+func (app *App) handleNewQueryTab(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("query-tab-new")
+	if app.overlayOpen() {
+		return nil
+	}
+
+	err := app.openQueryTab()
+	if err != nil {
+		return err
+	}
+
+	return app.render()
+}
+
+func (app *App) handleCloseQueryTab(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("query-tab-close")
+	if app.overlayOpen() {
+		return nil
+	}
+
+	err := app.closeQueryTab()
+	if err != nil {
+		return err
+	}
+
+	return app.render()
+}
+
+func (app *App) handleNextQueryTab(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("query-tab-next")
+	if app.overlayOpen() {
+		return nil
+	}
+
+	err := app.cycleQueryTab(1)
+	if err != nil {
+		return err
+	}
+
+	return app.render()
+}
+
+func (app *App) handlePrevQueryTab(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("query-tab-prev")
+	if app.overlayOpen() {
+		return nil
+	}
+
+	err := app.cycleQueryTab(-1)
+	if err != nil {
+		return err
+	}
+
+	return app.render()
+}
+
 func (app *App) handleTab(gui *gocui.Gui, view *gocui.View) error {
 	logEvent("tab")
 	next := focusSidebar
@@ -189,12 +426,7 @@ func (app *App) handleTab(gui *gocui.Gui, view *gocui.View) error {
 
 func (app *App) handleSidebarDown(gui *gocui.Gui, view *gocui.View) error {
 	logEvent("sidebar-down")
-	if len(app.tables) == 0 {
-		return nil
-	}
-
-	next := clampInt(app.selectedTableIndex+1, 0, len(app.tables)-1)
-	err := app.setSelectedTable(next)
+	err := app.selectSidebarItem(app.sidebarIndex + 1)
 	if err != nil {
 		return nil
 	}
@@ -204,12 +436,29 @@ func (app *App) handleSidebarDown(gui *gocui.Gui, view *gocui.View) error {
 
 func (app *App) handleSidebarUp(gui *gocui.Gui, view *gocui.View) error {
 	logEvent("sidebar-up")
-	if len(app.tables) == 0 {
+	err := app.selectSidebarItem(app.sidebarIndex - 1)
+	if err != nil {
 		return nil
 	}
 
-	next := clampInt(app.selectedTableIndex-1, 0, len(app.tables)-1)
-	err := app.setSelectedTable(next)
+	return app.render()
+}
+
+func (app *App) handleSidebarToggle(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("sidebar-toggle")
+	app.toggleSidebarItem()
+	return app.render()
+}
+
+func (app *App) handleSidebarExpand(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("sidebar-expand")
+	app.setSidebarExpanded(true)
+	return app.render()
+}
+
+func (app *App) handleSidebarCollapse(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("sidebar-collapse")
+	err := app.collapseSidebarItem()
 	if err != nil {
 		return nil
 	}
@@ -219,7 +468,16 @@ func (app *App) handleSidebarUp(gui *gocui.Gui, view *gocui.View) error {
 
 func (app *App) handleSidebarEnter(gui *gocui.Gui, view *gocui.View) error {
 	logEvent("sidebar-enter")
-	app.viewMode = viewTable
+	item, ok := app.currentSidebarItem()
+	if ok && item.Kind == sidebarGroup {
+		app.toggleSidebarItem()
+		return app.render()
+	}
+
+	if ok && isBrowsableObject(item.Object) {
+		app.viewMode = viewTable
+	}
+
 	err := app.setFocus(focusRows)
 	if err != nil {
 		return err
@@ -230,22 +488,48 @@ func (app *App) handleSidebarEnter(gui *gocui.Gui, view *gocui.View) error {
 
 func (app *App) handleRowsDown(gui *gocui.Gui, view *gocui.View) error {
 	logEvent("rows-down")
-	return app.scrollRows(1)
+	if app.viewMode == viewText {
+		return app.scrollRows(1)
+	}
+
+	return app.moveCursor(1, 0)
 }
 
 func (app *App) handleRowsUp(gui *gocui.Gui, view *gocui.View) error {
 	logEvent("rows-up")
-	return app.scrollRows(-1)
+	if app.viewMode == viewText {
+		return app.scrollRows(-1)
+	}
+
+	return app.moveCursor(-1, 0)
 }
 
 func (app *App) handleRowsLeft(gui *gocui.Gui, view *gocui.View) error {
 	logEvent("rows-left")
-	return app.scrollHorizontal(-1)
+	if app.viewMode == viewText {
+		return app.scrollHorizontal(-1)
+	}
+
+	return app.moveCursor(0, -1)
 }
 
 func (app *App) handleRowsRight(gui *gocui.Gui, view *gocui.View) error {
 	logEvent("rows-right")
-	return app.scrollHorizontal(1)
+	if app.viewMode == viewText {
+		return app.scrollHorizontal(1)
+	}
+
+	return app.moveCursor(0, 1)
+}
+
+func (app *App) handleRowsEnter(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("rows-enter")
+	_, err := app.openModalForCell(false)
+	if err != nil {
+		return err
+	}
+
+	return app.render()
 }
 
 func (app *App) handlePaneLeft(gui *gocui.Gui, view *gocui.View) error {
@@ -268,6 +552,31 @@ func (app *App) handleGlobalEsc(gui *gocui.Gui, view *gocui.View) error {
 		return app.handleModalClose(gui, view)
 	}
 
+	if app.prompt.Open {
+		return app.handlePromptCancel(gui, view)
+	}
+
+	if app.completion.Open {
+		app.closeCompletion()
+		return app.render()
+	}
+
+	if app.cancelSearch() {
+		return app.render()
+	}
+
+	if app.cancelQuery() {
+		return app.render()
+	}
+
+	if app.cancelExport() {
+		return app.render()
+	}
+
+	if app.cancelImport() {
+		return app.render()
+	}
+
 	return app.quit(gui, view)
 }
 
@@ -315,8 +624,22 @@ func (app *App) handlePaneUp(gui *gocui.Gui, view *gocui.View) error {
 
 func (app *App) handleQuerySubmit(gui *gocui.Gui, view *gocui.View) error {
 	logEvent("query-submit")
-	content := view.Buffer()
-	err := app.runQuery(content)
+	if app.completion.Open {
+		return app.acceptCompletion(view)
+	}
+
+	err := app.runStatementAtCursor(view)
+	if err != nil {
+		return nil
+	}
+
+	return app.render()
+}
+
+func (app *App) handleQueryRunAll(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("query-run-all")
+	app.closeCompletion()
+	err := app.runScript(view.Buffer())
 	if err != nil {
 		return nil
 	}
@@ -326,18 +649,47 @@ func (app *App) handleQuerySubmit(gui *gocui.Gui, view *gocui.View) error {
 
 func (app *App) handleQueryHistoryPrev(gui *gocui.Gui, view *gocui.View) error {
 	logEvent("query-history-prev")
+	if app.completion.Open {
+		return app.moveCompletionSelection(-1)
+	}
+
 	return app.moveHistorySelection(view, 1)
 }
 
 func (app *App) handleQueryHistoryNext(gui *gocui.Gui, view *gocui.View) error {
 	logEvent("query-history-next")
+	if app.completion.Open {
+		return app.moveCompletionSelection(1)
+	}
+
 	return app.moveHistorySelection(view, -1)
 }
 
+func (app *App) handleQueryComplete(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("query-complete")
+	if app.completion.Open {
+		return app.acceptCompletion(view)
+	}
+
+	handled, err := app.openCompletion(view, false)
+	if err != nil || handled {
+		return err
+	}
+
+	return app.handleTab(gui, view)
+}
+
+func (app *App) handleQueryCompleteAll(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("query-complete-all")
+	_, err := app.openCompletion(view, true)
+	return err
+}
+
 func (app *App) handleQueryNewline(gui *gocui.Gui, view *gocui.View) error {
 	start := time.Now()
 	app.resetHistorySelection()
 	view.EditWrite('\n')
+	highlightQueryView(view)
 	appendLatencyLog(start, time.Since(start))
 	return nil
 }
@@ -351,11 +703,11 @@ func (app *App) handleSidebarClick(gui *gocui.Gui, view *gocui.View) error {
 
 	_, cursorY := view.Cursor()
 	index := app.sidebarScroll + cursorY
-	if index < 0 || index >= len(app.tables) {
+	if index < 0 || index >= len(app.sidebarItems()) {
 		return nil
 	}
 
-	err = app.setSelectedTable(index)
+	err = app.selectSidebarItem(index)
 	if err != nil {
 		return nil
 	}
@@ -370,14 +722,34 @@ func (app *App) handleRowsClick(gui *gocui.Gui, view *gocui.View) error {
 		return err
 	}
 
-	opened, err := app.openModalForCell(view)
+	if app.moveCursorToClick(view) {
+		_, err = app.openModalForCell(true)
+		if err != nil {
+			return err
+		}
+	}
+
+	return app.render()
+}
+
+func (app *App) handleRowsHeaderClick(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("rows-header-click")
+	err := app.setFocus(focusRows)
 	if err != nil {
 		return err
 	}
-	if opened {
+
+	cursorX, _ := view.Cursor()
+	originX, _ := view.Origin()
+	tableView, messageView := app.buildTableView()
+	colIndex := hitTestColumn(tableView, cursorX+originX)
+	if messageView || colIndex < 0 {
 		return app.render()
 	}
 
+	row, _ := app.cursorPosition()
+	app.setCursorPosition(row, colIndex)
+	app.toggleSort(false)
 	return app.render()
 }
 
@@ -411,15 +783,282 @@ func (app *App) handleModalClose(gui *gocui.Gui, view *gocui.View) error {
 	return app.render()
 }
 
+func (app *App) handleModalEnter(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("modal-enter")
+	err := app.pickModalItem()
+	if err != nil {
+		return err
+	}
+
+	return app.render()
+}
+
+func (app *App) handleModalRun(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("modal-run")
+	err := app.runModalItem()
+	if err != nil {
+		return err
+	}
+
+	return app.render()
+}
+
 func (app *App) handleModalDown(gui *gocui.Gui, view *gocui.View) error {
 	logEvent("modal-down")
-	app.modalScroll += 1
+	app.moveModalSelection(1)
 	return app.render()
 }
 
 func (app *App) handleModalUp(gui *gocui.Gui, view *gocui.View) error {
 	logEvent("modal-up")
-	app.modalScroll -= 1
+	app.moveModalSelection(-1)
+	return app.render()
+}
+
+func (app *App) handleOpenHistory(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("history-open")
+	err := app.openHistoryBrowser()
+	if err != nil {
+		return err
+	}
+
+	return app.render()
+}
+
+func (app *App) handleOpenSnippets(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("snippets-open")
+	err := app.openSnippetPicker()
+	if err != nil {
+		return err
+	}
+
+	return app.render()
+}
+
+func (app *App) handleSaveSnippet(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("snippet-save")
+	app.closeCompletion()
+	err := app.saveSnippet(view)
+	if err != nil {
+		return err
+	}
+
+	return app.render()
+}
+
+func (app *App) handleHistoryLoad(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("history-load")
+	err := app.loadHistoryBrowserEntry()
+	if err != nil {
+		return err
+	}
+
+	return app.render()
+}
+
+func (app *App) handleHistoryClose(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("history-close")
+	err := app.closeHistoryBrowser()
+	if err != nil {
+		return err
+	}
+
+	return app.render()
+}
+
+func (app *App) handleHistoryDown(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("history-down")
+	app.moveHistoryBrowserSelection(1)
+	return app.render()
+}
+
+func (app *App) handleHistoryUp(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("history-up")
+	app.moveHistoryBrowserSelection(-1)
+	return app.render()
+}
+
+func (app *App) handleHistoryDelete(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("history-delete")
+	app.deleteHistoryBrowserEntry()
+	return app.render()
+}
+
+func (app *App) handleHistoryScope(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("history-scope")
+	app.toggleHistoryBrowserScope()
+	return app.render()
+}
+
+func (app *App) handleHistoryPin(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("history-pin")
+	app.togglePinHistoryBrowserEntry()
+	return app.render()
+}
+
+func (app *App) handlePromptSubmit(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("prompt-submit")
+	err := app.submitPrompt(view)
+	if err != nil {
+		return err
+	}
+
+	return app.render()
+}
+
+func (app *App) handlePromptCancel(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("prompt-cancel")
+	err := app.closePrompt()
+	if err != nil {
+		return err
+	}
+
+	return app.render()
+}
+
+func (app *App) handleSort(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("sort")
+	app.toggleSort(false)
+	return app.render()
+}
+
+func (app *App) handleSortAdd(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("sort-add")
+	app.toggleSort(true)
+	return app.render()
+}
+
+func (app *App) handleEditFilter(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("edit-filter")
+	err := app.editFilter()
+	if err != nil {
+		return err
+	}
+
+	return app.render()
+}
+
+func (app *App) handleAddCellFilter(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("add-cell-filter")
+	app.addCellFilter()
+	return app.render()
+}
+
+func (app *App) handleClearFilter(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("clear-filter")
+	app.clearFilter()
+	return app.render()
+}
+
+func (app *App) handleExport(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("export")
+	err := app.openExport()
+	if err != nil {
+		return err
+	}
+
+	return app.render()
+}
+
+func (app *App) handleImport(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("import")
+	err := app.openImport()
+	if err != nil {
+		return err
+	}
+
+	return app.render()
+}
+
+func (app *App) handleSearch(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("search")
+	err := app.openSearch()
+	if err != nil {
+		return err
+	}
+
+	return app.render()
+}
+
+func (app *App) handleSearchNext(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("search-next")
+	app.searchNext(true)
+	return app.render()
+}
+
+func (app *App) handleSearchPrev(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("search-prev")
+	app.searchNext(false)
+	return app.render()
+}
+
+func (app *App) handleToggleStructure(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("toggle-structure")
+	app.toggleStructure()
+	return app.render()
+}
+
+func (app *App) handleFollowForeignKey(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("follow-foreign-key")
+	err := app.followForeignKey()
+	if err != nil {
+		return err
+	}
+
+	return app.render()
+}
+
+func (app *App) handleShowReferences(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("show-references")
+	err := app.showInboundReferences()
+	if err != nil {
+		return err
+	}
+
+	return app.render()
+}
+
+func (app *App) handleGoBack(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("go-back")
+	app.goBack()
+	return app.render()
+}
+
+func (app *App) handleRowsEdit(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("rows-edit")
+	err := app.editCellAtCursor()
+	if err != nil {
+		return err
+	}
+
+	return app.render()
+}
+
+func (app *App) handleRowsInsert(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("rows-insert")
+	app.stageInsertRow()
+	return app.render()
+}
+
+func (app *App) handleRowsDelete(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("rows-delete")
+	app.toggleDeleteAtCursor()
+	return app.render()
+}
+
+func (app *App) handleCommitChanges(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("commit-changes")
+	err := app.commitChanges()
+	if err != nil {
+		return err
+	}
+
+	return app.render()
+}
+
+func (app *App) handleDiscardChanges(gui *gocui.Gui, view *gocui.View) error {
+	logEvent("discard-changes")
+	app.discardChanges()
 	return app.render()
 }
 
@@ -435,6 +1074,12 @@ func (app *App) scrollRows(delta int) error {
 
 	if app.viewMode == viewQuery {
 		app.queryState.Offset += delta
+		app.keepCursorInViewport()
+		return app.render()
+	}
+
+	if app.viewMode == viewText {
+		app.textState.Offset += delta
 		return app.render()
 	}
 
@@ -443,6 +1088,7 @@ func (app *App) scrollRows(delta int) error {
 	}
 
 	app.tableState.Offset += delta
+	app.keepCursorInViewport()
 	return app.render()
 }
 
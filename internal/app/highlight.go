@@ -0,0 +1,39 @@
+package app
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/awesome-gocui/gocui"
+
+	"squlito/internal/sqlsyntax"
+)
+
+func highlightQueryView(view *gocui.View) {
+	if view == nil {
+		return
+	}
+
+	text, cursor := queryTextAtCursor(view)
+	originX, originY := view.Origin()
+	view.Clear()
+	view.WriteString(sqlsyntax.Highlight(text))
+
+	before := text[:cursor]
+	cursorY := strings.Count(before, "\n")
+	cursorX := utf8.RuneCountInString(before[strings.LastIndexByte(before, '\n')+1:])
+	_ = view.SetCursor(cursorX, cursorY)
+	_ = view.SetOrigin(originX, originY)
+}
+
+func unbalancedQuoteLabel(text string) string {
+	token, ok := sqlsyntax.FirstUnterminated(text)
+	if !ok {
+		return ""
+	}
+
+	if token.Kind == sqlsyntax.TokenString {
+		return "unclosed string"
+	}
+	return "unclosed identifier"
+}
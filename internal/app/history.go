@@ -1,31 +1,46 @@
 package app
 
 import (
-	"database/sql"
-	"net/url"
-	"os"
-	"path/filepath"
+	"maps"
+	"slices"
 	"time"
 	"unicode/utf8"
 
 	"github.com/awesome-gocui/gocui"
-)
 
-const historyTableName = "query_history"
+	"squlito/internal/history"
+	"squlito/internal/snippets"
+	"squlito/internal/sqlsyntax"
+)
 
 func (app *App) initHistory() {
-	dbConn, err := openHistoryDB()
+	dbConn, err := history.Open()
 	if err != nil {
 		return
 	}
 
-	err = ensureHistorySchema(dbConn)
+	err = history.EnsureSchema(dbConn)
+	if err == nil {
+		err = snippets.EnsureSchema(dbConn)
+	}
 	if err != nil {
 		_ = dbConn.Close()
 		return
 	}
 
-	entries, err := loadQueryHistory(dbConn, historyLimit)
+	retention, err := history.RetentionFromEnv()
+	if err != nil {
+		app.setStatusMessage("Ignoring history retention setting: " + err.Error())
+	}
+	err = history.Prune(dbConn, retention, time.Now())
+	if err != nil {
+		app.setStatusMessage("Pruning query history failed: " + err.Error())
+	}
+
+	if app.historyDatabase == "" {
+		app.historyDatabase = history.DatabaseKey(app.dbPath)
+	}
+	entries, err := history.Load(dbConn, app.historyDatabase, historyLimit)
 	if err != nil {
 		entries = nil
 	}
@@ -34,24 +49,83 @@ func (app *App) initHistory() {
 	app.historyEntries = entries
 	app.historyIndex = -1
 	app.historyDraft = ""
+
+	for index := len(entries) - 1; index >= 0; index-- {
+		maps.Copy(app.parameters, entries[index].Bindings)
+	}
 }
 
-func (app *App) recordHistory(sqlText string) {
+func (app *App) recordHistory(tab *QueryTab, sqlText string, bindings map[string]string) {
+	tab.historyID = 0
 	if app.historyDB == nil {
 		return
 	}
 
-	entry, err := insertQueryHistory(app.historyDB, sqlText)
+	entry, err := history.Record(app.historyDB, app.historyDatabase, sqlText, bindings)
 	if err != nil {
 		return
 	}
 
-	app.historyEntries = append([]QueryHistoryEntry{entry}, app.historyEntries...)
+	tab.historyID = entry.ID
+	app.historyEntries = slices.DeleteFunc(app.historyEntries, func(candidate history.Entry) bool {
+		return candidate.ID == entry.ID
+	})
+	app.historyEntries = append([]history.Entry{entry}, app.historyEntries...)
+	history.Sort(app.historyEntries)
 	if len(app.historyEntries) > historyLimit {
 		app.historyEntries = app.historyEntries[:historyLimit]
 	}
 }
 
+func (app *App) recordHistoryResult(tab *QueryTab) {
+	if app.historyDB == nil || tab.historyID == 0 {
+		return
+	}
+
+	state := tab.State
+	result := history.Result{
+		Duration:  state.Duration,
+		RowCount:  state.RowCount,
+		Error:     state.Error,
+		Truncated: state.Error == "" && (state.Truncated || !state.Done),
+	}
+
+	err := history.UpdateResult(app.historyDB, tab.historyID, result)
+	if err != nil {
+		return
+	}
+
+	for index := range app.historyEntries {
+		if app.historyEntries[index].ID == tab.historyID {
+			app.historyEntries[index].Result = result
+		}
+	}
+
+	if state.Error != "" || state.Done || state.Truncated {
+		tab.historyID = 0
+	}
+}
+
+func (app *App) historyScope() string {
+	if app.historyAllDatabases {
+		return ""
+	}
+
+	return app.historyDatabase
+}
+
+func (app *App) toggleHistoryScope() error {
+	app.historyAllDatabases = !app.historyAllDatabases
+	entries, err := history.Load(app.historyDB, app.historyScope(), historyLimit)
+	if err != nil {
+		return err
+	}
+
+	app.historyEntries = entries
+	app.resetHistorySelection()
+	return nil
+}
+
 func (app *App) resetHistorySelection() {
 	if app.historyIndex == -1 && app.historyDraft == "" {
 		return
@@ -116,7 +190,7 @@ func (app *App) setQueryViewContent(view *gocui.View, value string) {
 	}
 
 	view.Clear()
-	view.WriteString(value)
+	view.WriteString(sqlsyntax.Highlight(value))
 	lines := view.BufferLines()
 	if len(lines) == 0 {
 		_ = view.SetCursor(0, 0)
@@ -129,108 +203,3 @@ func (app *App) setQueryViewContent(view *gocui.View, value string) {
 	_ = view.SetCursor(0, 0)
 	view.MoveCursor(lastColumn, lastIndex)
 }
-
-func openHistoryDB() (*sql.DB, error) {
-	path, err := historyDBPath()
-	if err != nil {
-		return nil, err
-	}
-
-	err = os.MkdirAll(filepath.Dir(path), 0o755)
-	if err != nil {
-		return nil, err
-	}
-
-	dsn := makeHistoryDsn(path)
-	dbConn, err := sql.Open("sqlite", dsn)
-	if err != nil {
-		return nil, err
-	}
-
-	_, err = dbConn.Exec("PRAGMA foreign_keys = ON")
-	if err != nil {
-		closeErr := dbConn.Close()
-		if closeErr != nil {
-			return nil, closeErr
-		}
-		return nil, err
-	}
-
-	return dbConn, nil
-}
-
-func historyDBPath() (string, error) {
-	configDir, err := os.UserConfigDir()
-	if err != nil {
-		return "", err
-	}
-
-	return filepath.Join(configDir, "squlito", "history.db"), nil
-}
-
-func makeHistoryDsn(path string) string {
-	escaped := url.PathEscape(path)
-	return "file:" + escaped + "?mode=rwc"
-}
-
-func ensureHistorySchema(dbConn *sql.DB) error {
-	createTable := "CREATE TABLE IF NOT EXISTS " + historyTableName + " (id INTEGER PRIMARY KEY AUTOINCREMENT, sql TEXT NOT NULL, created_at TEXT NOT NULL)"
-	_, err := dbConn.Exec(createTable)
-	if err != nil {
-		return err
-	}
-
-	createIndex := "CREATE INDEX IF NOT EXISTS query_history_created_at ON " + historyTableName + " (created_at DESC)"
-	_, err = dbConn.Exec(createIndex)
-	return err
-}
-
-func loadQueryHistory(dbConn *sql.DB, limit int) ([]QueryHistoryEntry, error) {
-	if limit <= 0 {
-		return []QueryHistoryEntry{}, nil
-	}
-
-	rows, err := dbConn.Query("SELECT id, sql, created_at FROM "+historyTableName+" ORDER BY created_at DESC LIMIT ?", limit)
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		_ = rows.Close()
-	}()
-
-	entries := []QueryHistoryEntry{}
-	for rows.Next() {
-		var entry QueryHistoryEntry
-		err = rows.Scan(&entry.ID, &entry.SQL, &entry.CreatedAt)
-		if err != nil {
-			return nil, err
-		}
-		entries = append(entries, entry)
-	}
-
-	err = rows.Err()
-	if err != nil {
-		return nil, err
-	}
-
-	return entries, nil
-}
-
-func insertQueryHistory(dbConn *sql.DB, sqlText string) (QueryHistoryEntry, error) {
-	createdAt := time.Now().UTC().Format(time.RFC3339Nano)
-	result, err := dbConn.Exec("INSERT INTO "+historyTableName+" (sql, created_at) VALUES (?, ?)", sqlText, createdAt)
-	if err != nil {
-		return QueryHistoryEntry{}, err
-	}
-
-	id, err := result.LastInsertId()
-	if err != nil {
-		return QueryHistoryEntry{}, err
-	}
-
-	return QueryHistoryEntry{
-		ID:        id,
-		SQL:       sqlText,
-		CreatedAt: createdAt,
-	}, nil
-}
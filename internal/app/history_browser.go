@@ -0,0 +1,410 @@
+package app
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/awesome-gocui/gocui"
+
+	"squlito/internal/completion"
+	"squlito/internal/history"
+	"squlito/internal/sqlsyntax"
+)
+
+const (
+	historySearchViewName  = "historySearch"
+	historyListViewName    = "historyList"
+	historyPreviewViewName = "historyPreview"
+)
+
+type historySearchEditor struct {
+	app *App
+}
+
+func (editor historySearchEditor) Edit(view *gocui.View, key gocui.Key, ch rune, mod gocui.Modifier) {
+	logKeyEvent("history", key, ch, mod)
+	gocui.DefaultEditor.Edit(view, key, ch, mod)
+	editor.app.filterHistoryBrowser(strings.TrimRight(view.Buffer(), "\n"))
+}
+
+func (app *App) layoutHistoryBrowser(gui *gocui.Gui, maxX int, maxY int) error {
+	width := clampInt(int(float64(maxX)*0.85), 40, maxX-4)
+	height := clampInt(int(float64(maxY)*0.75), 10, maxY-2)
+	if width < 20 || height < 8 {
+		return nil
+	}
+
+	x0 := (maxX - width) / 2
+	y0 := (maxY - height) / 2
+	x1 := x0 + width - 1
+	y1 := y0 + height - 1
+	split := x0 + width*55/100
+
+	searchView, err := gui.SetView(historySearchViewName, x0, y0, x1, y0+2, 0)
+	if err != nil && err != gocui.ErrUnknownView {
+		return err
+	}
+	if err == gocui.ErrUnknownView {
+		searchView.Wrap = false
+		searchView.Frame = true
+		searchView.Editable = true
+		searchView.Editor = historySearchEditor{app: app}
+		searchView.WriteString(app.historyBrowser.Query)
+		_ = searchView.SetCursor(utf8.RuneCountInString(app.historyBrowser.Query), 0)
+	}
+
+	listView, err := gui.SetView(historyListViewName, x0, y0+3, split, y1, 0)
+	if err != nil && err != gocui.ErrUnknownView {
+		return err
+	}
+	if err == gocui.ErrUnknownView {
+		listView.Wrap = false
+		listView.Frame = true
+	}
+
+	previewView, err := gui.SetView(historyPreviewViewName, split+1, y0+3, x1, y1, 0)
+	if err != nil && err != gocui.ErrUnknownView {
+		return err
+	}
+	if err == gocui.ErrUnknownView {
+		previewView.Wrap = true
+		previewView.Frame = true
+		previewView.Title = "Preview"
+	}
+
+	_, _ = gui.SetViewOnTop(historyListViewName)
+	_, _ = gui.SetViewOnTop(historyPreviewViewName)
+	_, _ = gui.SetViewOnTop(historySearchViewName)
+	return nil
+}
+
+func (app *App) clearHistoryBrowser(gui *gocui.Gui) {
+	if gui == nil {
+		return
+	}
+
+	for _, name := range []string{historySearchViewName, historyListViewName, historyPreviewViewName} {
+		_, err := gui.View(name)
+		if err != nil {
+			continue
+		}
+
+		_ = gui.DeleteView(name)
+	}
+}
+
+func (app *App) openHistoryBrowser() error {
+	if app.overlayOpen() {
+		return nil
+	}
+
+	if app.historyDB == nil {
+		app.setStatusMessage("Query history is unavailable")
+		return nil
+	}
+
+	entries, err := history.Load(app.historyDB, app.historyScope(), history.Unlimited)
+	if err != nil {
+		app.setStatusMessage("Loading history failed: " + err.Error())
+		return nil
+	}
+
+	app.clearHistoryBrowser(app.gui)
+	app.historyBrowser = HistoryBrowserState{
+		Open:      true,
+		Query:     "",
+		All:       entries,
+		Matches:   matchHistoryEntries(entries, ""),
+		Index:     0,
+		Scroll:    0,
+		PrevFocus: app.focusArea,
+	}
+
+	return app.setFocus(focusHistory)
+}
+
+func (app *App) closeHistoryBrowser() error {
+	if !app.historyBrowser.Open {
+		return nil
+	}
+
+	prevFocus := app.historyBrowser.PrevFocus
+	app.historyBrowser = HistoryBrowserState{
+		Open:      false,
+		Query:     "",
+		All:       nil,
+		Matches:   nil,
+		Index:     0,
+		Scroll:    0,
+		PrevFocus: focusSidebar,
+	}
+
+	return app.setFocus(prevFocus)
+}
+
+func (app *App) filterHistoryBrowser(query string) {
+	if query == app.historyBrowser.Query {
+		return
+	}
+
+	app.historyBrowser.Query = query
+	app.historyBrowser.Matches = matchHistoryEntries(app.historyBrowser.All, query)
+	app.historyBrowser.Index = 0
+	app.historyBrowser.Scroll = 0
+}
+
+func matchHistoryEntries(entries []history.Entry, query string) []history.Entry {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return pinnedHistoryFirst(entries)
+	}
+
+	exact := []history.Entry{}
+	fuzzy := []history.Entry{}
+	for _, entry := range entries {
+		text := strings.ToLower(strings.Join(strings.Fields(entry.SQL), " "))
+		matched := true
+		substring := true
+		for _, term := range terms {
+			if strings.Contains(text, term) {
+				continue
+			}
+
+			substring = false
+			_, ok := completion.Match(term, text)
+			if !ok {
+				matched = false
+				break
+			}
+		}
+
+		if !matched {
+			continue
+		}
+		if substring {
+			exact = append(exact, entry)
+			continue
+		}
+		fuzzy = append(fuzzy, entry)
+	}
+
+	return pinnedHistoryFirst(append(exact, fuzzy...))
+}
+
+func pinnedHistoryFirst(entries []history.Entry) []history.Entry {
+	pinned := []history.Entry{}
+	rest := []history.Entry{}
+	for _, entry := range entries {
+		if entry.Pinned {
+			pinned = append(pinned, entry)
+			continue
+		}
+		rest = append(rest, entry)
+	}
+
+	return append(pinned, rest...)
+}
+
+func (app *App) toggleHistoryBrowserScope() {
+	err := app.toggleHistoryScope()
+	if err != nil {
+		app.setStatusMessage("Loading history failed: " + err.Error())
+		return
+	}
+
+	entries, err := history.Load(app.historyDB, app.historyScope(), history.Unlimited)
+	if err != nil {
+		app.setStatusMessage("Loading history failed: " + err.Error())
+		return
+	}
+
+	app.historyBrowser.All = entries
+	app.historyBrowser.Matches = matchHistoryEntries(entries, app.historyBrowser.Query)
+	app.historyBrowser.Index = 0
+	app.historyBrowser.Scroll = 0
+}
+
+func (app *App) moveHistoryBrowserSelection(delta int) {
+	if len(app.historyBrowser.Matches) == 0 {
+		return
+	}
+
+	app.historyBrowser.Index = clampInt(app.historyBrowser.Index+delta, 0, len(app.historyBrowser.Matches)-1)
+}
+
+func (app *App) selectedHistoryEntry() (entry history.Entry, ok bool) {
+	matches := app.historyBrowser.Matches
+	if app.historyBrowser.Index < 0 || app.historyBrowser.Index >= len(matches) {
+		return entry, false
+	}
+
+	return matches[app.historyBrowser.Index], true
+}
+
+func (app *App) loadHistoryBrowserEntry() error {
+	entry, ok := app.selectedHistoryEntry()
+	if !ok {
+		return nil
+	}
+
+	err := app.closeHistoryBrowser()
+	if err != nil {
+		return err
+	}
+
+	err = app.setFocus(focusQuery)
+	if err != nil {
+		return err
+	}
+
+	view, err := app.gui.View("query")
+	if err != nil {
+		return nil
+	}
+
+	maps.Copy(app.parameters, entry.Bindings)
+	app.resetHistorySelection()
+	app.setQueryViewContent(view, entry.SQL)
+	return nil
+}
+
+func (app *App) deleteHistoryBrowserEntry() {
+	entry, ok := app.selectedHistoryEntry()
+	if !ok {
+		return
+	}
+
+	err := history.Delete(app.historyDB, entry.ID)
+	if err != nil {
+		app.setStatusMessage("Deleting history entry failed: " + err.Error())
+		return
+	}
+
+	isEntry := func(candidate history.Entry) bool {
+		return candidate.ID == entry.ID
+	}
+	app.historyBrowser.All = slices.DeleteFunc(app.historyBrowser.All, isEntry)
+	app.historyBrowser.Matches = matchHistoryEntries(app.historyBrowser.All, app.historyBrowser.Query)
+	app.historyBrowser.Index = clampInt(app.historyBrowser.Index, 0, max(0, len(app.historyBrowser.Matches)-1))
+	app.historyEntries = slices.DeleteFunc(app.historyEntries, isEntry)
+	app.resetHistorySelection()
+	app.setStatusMessage("Deleted history entry")
+}
+
+func (app *App) togglePinHistoryBrowserEntry() {
+	entry, ok := app.selectedHistoryEntry()
+	if !ok {
+		return
+	}
+
+	pinned := !entry.Pinned
+	err := history.SetPinned(app.historyDB, entry.ID, pinned)
+	if err != nil {
+		app.setStatusMessage("Pinning history entry failed: " + err.Error())
+		return
+	}
+
+	for _, entries := range [][]history.Entry{app.historyBrowser.All, app.historyEntries} {
+		for index := range entries {
+			if entries[index].ID == entry.ID {
+				entries[index].Pinned = pinned
+			}
+		}
+	}
+
+	history.Sort(app.historyBrowser.All)
+	history.Sort(app.historyEntries)
+	app.historyBrowser.Matches = matchHistoryEntries(app.historyBrowser.All, app.historyBrowser.Query)
+	app.historyBrowser.Index = max(0, slices.IndexFunc(app.historyBrowser.Matches, func(candidate history.Entry) bool {
+		return candidate.ID == entry.ID
+	}))
+
+	if pinned {
+		app.setStatusMessage("Pinned history entry; it is kept when history is pruned")
+		return
+	}
+	app.setStatusMessage("Unpinned history entry")
+}
+
+func historyEntryLabel(entry history.Entry, width int, showDatabase bool) string {
+	label := formatHistoryTime(entry.LastRunAt) + "  "
+	if entry.Pinned {
+		label += "* "
+	}
+	if entry.Result.Error != "" {
+		label += "! "
+	}
+	if showDatabase {
+		label += "[" + historyDatabaseLabel(entry.DBPath) + "] "
+	}
+
+	label += strings.Join(strings.Fields(entry.SQL), " ")
+	return truncateLine(label, width)
+}
+
+func historyEntryPreview(entry history.Entry) string {
+	lines := []string{
+		sqlsyntax.Highlight(entry.SQL),
+		"",
+		"Database: " + historyDatabaseName(entry.DBPath),
+	}
+
+	result := entry.Result
+	if result.Error != "" {
+		lines = append(lines, "Error: "+result.Error)
+	} else {
+		rows := fmt.Sprintf("Rows: %d", result.RowCount)
+		if result.Truncated {
+			rows += "+ (not fully read)"
+		}
+		lines = append(lines, rows)
+	}
+	lines = append(lines, "Duration: "+formatDuration(result.Duration))
+
+	if entry.RunCount > 1 {
+		lines = append(lines, fmt.Sprintf("Runs: %d since %s", entry.RunCount, formatHistoryTime(entry.CreatedAt)))
+	}
+	if entry.Pinned {
+		lines = append(lines, "Pinned")
+	}
+
+	if len(entry.Bindings) > 0 {
+		lines = append(lines, "", "Parameters:")
+		for _, name := range slices.Sorted(maps.Keys(entry.Bindings)) {
+			lines = append(lines, fmt.Sprintf("  %s = %s", name, entry.Bindings[name]))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func historyDatabaseLabel(dbPath string) string {
+	names := []string{}
+	for path := range strings.SplitSeq(historyDatabaseName(dbPath), ", ") {
+		names = append(names, filepath.Base(path))
+	}
+
+	return strings.Join(names, ", ")
+}
+
+func historyDatabaseName(dbPath string) string {
+	if dbPath == "" {
+		return "unknown"
+	}
+
+	return dbPath
+}
+
+func formatHistoryTime(value string) string {
+	parsed, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return value
+	}
+
+	return parsed.Local().Format("2006-01-02 15:04")
+}
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/awesome-gocui/gocui"
+
+	"squlito/internal/importer"
+	"squlito/internal/tableformat"
+)
+
+func (app *App) openImport() error {
+	if !app.writable {
+		app.setStatusMessage("Importing needs a writable database: restart with --write")
+		return nil
+	}
+
+	if app.importState.Running {
+		app.setStatusMessage("An import is already running: Esc cancels it")
+		return nil
+	}
+
+	if len(app.pendingChanges) > 0 {
+		app.setStatusMessage("Commit or discard pending changes before importing")
+		return nil
+	}
+
+	return app.openPrompt("Import file (.csv .tsv .json .ndjson)", "", func(value string) error {
+		path := strings.TrimSpace(value)
+		if path == "" {
+			return nil
+		}
+
+		if _, ok := importer.FormatFromPath(path); !ok {
+			app.setStatusMessage("Unknown import format: use .csv, .tsv, .json or .ndjson")
+			return nil
+		}
+
+		return app.openPrompt("Table name", importer.TableNameFromPath(path), func(table string) error {
+			table = strings.TrimSpace(table)
+			if table == "" {
+				return nil
+			}
+
+			app.startImport(path, table)
+			return nil
+		})
+	})
+}
+
+func (app *App) startImport(path string, table string) {
+	app.stopQueryStreams()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	app.importCancel = cancel
+	app.importState = ImportState{
+		Running:    true,
+		Path:       path,
+		Table:      table,
+		Rows:       0,
+		BytesRead:  0,
+		TotalBytes: 0,
+		StartedAt:  time.Now(),
+	}
+	dbConn := app.db
+
+	go func() {
+		result, err := importer.ImportFile(ctx, dbConn, importer.Options{
+			Path:   path,
+			Table:  table,
+			Format: "",
+			Progress: func(progress importer.Progress) {
+				app.gui.Update(func(gui *gocui.Gui) error {
+					app.importState.Rows = progress.Rows
+					app.importState.BytesRead = progress.BytesRead
+					app.importState.TotalBytes = progress.TotalBytes
+					return app.render()
+				})
+			},
+		})
+		if err != nil && ctx.Err() != nil {
+			err = ctx.Err()
+		}
+
+		app.gui.Update(func(gui *gocui.Gui) error {
+			app.finishImport(result, err)
+			return app.render()
+		})
+	}()
+}
+
+func (app *App) finishImport(result importer.Result, err error) {
+	state := app.importState
+	app.importState.Running = false
+	app.importCancel = nil
+
+	if errors.Is(err, context.Canceled) {
+		app.setStatusMessage("Import cancelled")
+		return
+	}
+
+	if err != nil {
+		app.setStatusMessage("Import failed: " + err.Error())
+		return
+	}
+
+	err = app.loadSchema()
+	if err != nil {
+		app.setStatusMessage("Reloading schema failed: " + err.Error())
+		return
+	}
+
+	if object, ok := app.schemaObject(result.Table); ok {
+		app.revealSidebarObject(object.Name)
+		_ = app.selectTable(object)
+	}
+
+	app.setStatusMessage(fmt.Sprintf("Imported %d rows into %s in %s", result.Rows, result.Table, formatDuration(time.Since(state.StartedAt))))
+}
+
+func (app *App) cancelImport() bool {
+	if !app.importState.Running || app.importCancel == nil {
+		return false
+	}
+
+	app.importCancel()
+	app.importCancel = nil
+	return true
+}
+
+func (app *App) importProgress() string {
+	state := app.importState
+	status := fmt.Sprintf("Importing %s into %s: %d rows", state.Path, state.Table, state.Rows)
+	if state.TotalBytes <= 0 {
+		return status
+	}
+
+	percent := min(100, state.BytesRead*100/state.TotalBytes)
+	return fmt.Sprintf("%s %s %d%%", status, tableformat.ProgressBar(state.BytesRead, state.TotalBytes, progressBarWidth), percent)
+}
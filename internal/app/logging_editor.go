@@ -18,7 +18,9 @@ func (editor loggingEditor) Edit(view *gocui.View, key gocui.Key, ch rune, mod g
 	}
 	if isQueryEditKey(key, ch) {
 		editor.app.resetHistorySelection()
+		highlightQueryView(view)
 	}
+	editor.app.refreshCompletion(view)
 }
 
 func isQueryEditKey(key gocui.Key, ch rune) bool {
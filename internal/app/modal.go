@@ -12,6 +12,12 @@ import (
 
 const modalViewName = "modal"
 
+type pickerItem struct {
+	Label    string
+	OnSelect func() error
+	OnRun    func() error
+}
+
 func (app *App) layoutModal(gui *gocui.Gui, maxX int, maxY int) error {
 	width := int(float64(maxX) * 0.7)
 	height := int(float64(maxY) * 0.6)
@@ -71,6 +77,8 @@ func (app *App) openModal(title string, body string) error {
 	app.modalTitle = title
 	app.modalBody = body
 	app.modalScroll = 0
+	app.modalItems = nil
+	app.modalIndex = 0
 	app.modalPrevFocus = app.focusArea
 
 	if strings.TrimSpace(app.modalTitle) == "" {
@@ -89,84 +97,137 @@ func (app *App) closeModal() error {
 	app.modalTitle = ""
 	app.modalBody = ""
 	app.modalScroll = 0
+	app.modalItems = nil
+	app.modalIndex = 0
 
 	return app.setFocus(app.modalPrevFocus)
 }
 
-func (app *App) openModalForCell(view *gocui.View) (bool, error) {
-	if view == nil {
-		return false, nil
-	}
+func (app *App) overlayOpen() bool {
+	return app.modalOpen || app.prompt.Open || app.historyBrowser.Open
+}
 
-	if app.modalOpen {
-		return false, nil
+func (app *App) openPicker(title string, items []pickerItem) error {
+	err := app.openModal(title, "")
+	if err != nil {
+		return err
 	}
 
-	if app.viewMode == viewTable && app.tableState.Name == "" {
-		return false, nil
-	}
+	app.modalItems = items
+	return nil
+}
 
-	if app.viewMode == viewQuery && app.queryState.Error != "" {
-		return false, nil
+func (app *App) moveModalSelection(delta int) {
+	if len(app.modalItems) == 0 {
+		app.modalScroll += delta
+		return
 	}
 
-	cursorX, cursorY := view.Cursor()
-	originX, originY := view.Origin()
-	x := cursorX + originX
-	y := cursorY + originY
+	app.modalIndex = clampInt(app.modalIndex+delta, 0, len(app.modalItems)-1)
+}
 
-	if x < 0 || y < 0 {
-		return false, nil
+func (app *App) pickModalItem() error {
+	if len(app.modalItems) == 0 {
+		return app.closeModal()
 	}
 
-	tableView, messageView := app.buildTableView()
-	if messageView {
-		return false, nil
+	item := app.modalItems[app.modalIndex]
+	err := app.closeModal()
+	if err != nil {
+		return err
 	}
 
-	if y >= tableView.RowCount {
-		return false, nil
+	return item.OnSelect()
+}
+
+func (app *App) runModalItem() error {
+	if len(app.modalItems) == 0 {
+		return nil
 	}
 
-	colIndex := hitTestColumn(tableView, x)
-	if colIndex < 0 {
-		return false, nil
+	item := app.modalItems[app.modalIndex]
+	if item.OnRun == nil {
+		return nil
 	}
 
-	columns := app.tableState.Columns
-	rows := app.tableState.Rows
-	if app.viewMode == viewQuery {
-		columns = app.queryState.Columns
-		rows = app.queryState.AllRows
+	err := app.closeModal()
+	if err != nil {
+		return err
 	}
 
-	if colIndex >= len(columns) || y >= len(rows) {
+	return item.OnRun()
+}
+
+func (app *App) openModalForCell(onlyTruncated bool) (bool, error) {
+	if app.modalOpen {
 		return false, nil
 	}
 
-	columnName := columns[colIndex]
-	row := rows[y]
-	value, ok := row[columnName]
+	cell, ok := app.cellAtCursor()
 	if !ok {
 		return false, nil
 	}
 
-	raw := tableformat.FormatCell(value)
-	columnWidth := tableView.ColumnWidths[colIndex]
-	if len(raw) <= columnWidth {
+	raw := tableformat.FormatCell(cell.Value)
+	if onlyTruncated && len(raw) <= cell.Width {
 		return false, nil
 	}
 
 	formatted := maybeIndentJSON(raw)
 
 	title := "Value"
-	if columnName != "" {
-		title = "Value: " + columnName
+	if cell.Column != "" {
+		title = "Value: " + cell.Column
 	}
 
 	return true, app.openModal(title, formatted)
 }
 
+func (app *App) cellAtCursor() (cell gridCell, ok bool) {
+	if app.viewMode == viewText {
+		return cell, false
+	}
+
+	if app.viewMode == viewTable && app.tableState.Name == "" {
+		return cell, false
+	}
+
+	if app.viewMode == viewQuery && app.queryState.Error != "" {
+		return cell, false
+	}
+
+	tableView, messageView := app.buildTableView()
+	if messageView {
+		return cell, false
+	}
+
+	row, colIndex := app.cursorPosition()
+	rowIndex := row - app.cursorRowBase()
+	if rowIndex < 0 || rowIndex >= tableView.RowCount {
+		return cell, false
+	}
+
+	columns := app.tableState.Columns
+	rows := app.displayTableRows()
+	if app.viewMode == viewQuery {
+		columns = app.queryState.Columns
+		rows = app.queryState.AllRows
+	}
+
+	if colIndex >= len(columns) || rowIndex >= len(rows) {
+		return cell, false
+	}
+
+	columnName := columns[colIndex]
+	return gridCell{
+		RowIndex: rowIndex,
+		ColIndex: colIndex,
+		Column:   columnName,
+		Value:    rows[rowIndex][columnName],
+		Width:    tableView.ColumnWidths[colIndex],
+	}, true
+}
+
 func hitTestColumn(tableView tableformat.TableRender, x int) int {
 	if x < 0 {
 		return -1
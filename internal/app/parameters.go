@@ -0,0 +1,39 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"squlito/internal/sqlsyntax"
+)
+
+func (app *App) promptQueryParameters(sqlText string, parameters []sqlsyntax.Parameter, values map[string]string) error {
+	parameter := parameters[len(values)]
+	title := fmt.Sprintf("Parameter %s (%d/%d): NULL, 42, 1.5, 'text' or text", parameter.Name, len(values)+1, len(parameters))
+
+	return app.openPrompt(title, app.parameterDefault(sqlText, parameter.Name), func(value string) error {
+		values[parameter.Name] = value
+		app.parameters[parameter.Name] = value
+		if len(values) < len(parameters) {
+			return app.promptQueryParameters(sqlText, parameters, values)
+		}
+
+		return app.startQuery(sqlText, values)
+	})
+}
+
+func (app *App) parameterDefault(sqlText string, name string) string {
+	trimmed := strings.TrimSpace(sqlText)
+	for _, entry := range app.historyEntries {
+		if entry.SQL != trimmed {
+			continue
+		}
+
+		value, ok := entry.Bindings[name]
+		if ok {
+			return value
+		}
+	}
+
+	return app.parameters[name]
+}
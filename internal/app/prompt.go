@@ -0,0 +1,118 @@
+package app
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/awesome-gocui/gocui"
+)
+
+const promptViewName = "prompt"
+
+func (app *App) layoutPrompt(gui *gocui.Gui, maxX int, maxY int) error {
+	width := int(float64(maxX) * 0.6)
+	height := 3
+
+	if width < 30 {
+		width = 30
+	}
+	if width > maxX-4 {
+		width = maxX - 4
+	}
+
+	if width < 2 || maxY < height+2 {
+		return nil
+	}
+
+	x0 := (maxX - width) / 2
+	y0 := (maxY - height) / 2
+	x1 := x0 + width - 1
+	y1 := y0 + height - 1
+
+	view, err := gui.SetView(promptViewName, x0, y0, x1, y1, 0)
+	if err != nil && err != gocui.ErrUnknownView {
+		return err
+	}
+	if err == gocui.ErrUnknownView {
+		view.Title = app.prompt.Title
+		view.Wrap = false
+		view.Frame = true
+		view.Editable = true
+		view.Editor = gocui.DefaultEditor
+		view.WriteString(app.prompt.Value)
+		_ = view.SetCursor(utf8.RuneCountInString(app.prompt.Value), 0)
+	}
+
+	_, _ = gui.SetViewOnTop(promptViewName)
+	return nil
+}
+
+func (app *App) clearPrompt(gui *gocui.Gui) {
+	if gui == nil {
+		return
+	}
+
+	_, err := gui.View(promptViewName)
+	if err != nil {
+		return
+	}
+
+	_ = gui.DeleteView(promptViewName)
+}
+
+func (app *App) openPrompt(title string, value string, onSubmit func(value string) error) error {
+	if app.overlayOpen() {
+		return nil
+	}
+
+	app.clearPrompt(app.gui)
+	app.prompt = PromptState{
+		Open:      true,
+		Title:     title,
+		Value:     value,
+		PrevFocus: app.focusArea,
+		OnSubmit:  onSubmit,
+	}
+
+	return app.setFocus(focusPrompt)
+}
+
+func (app *App) closePrompt() error {
+	if !app.prompt.Open {
+		return nil
+	}
+
+	prevFocus := app.prompt.PrevFocus
+	app.prompt = PromptState{
+		Open:      false,
+		Title:     "",
+		Value:     "",
+		PrevFocus: focusSidebar,
+		OnSubmit:  nil,
+	}
+
+	return app.setFocus(prevFocus)
+}
+
+func (app *App) submitPrompt(view *gocui.View) error {
+	if !app.prompt.Open {
+		return nil
+	}
+
+	value := ""
+	if view != nil {
+		value = strings.TrimRight(view.Buffer(), "\n")
+	}
+
+	onSubmit := app.prompt.OnSubmit
+	err := app.closePrompt()
+	if err != nil {
+		return err
+	}
+
+	if onSubmit == nil {
+		return nil
+	}
+
+	return onSubmit(value)
+}
@@ -3,9 +3,11 @@ package app
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/awesome-gocui/gocui"
 
+	"squlito/internal/db"
 	"squlito/internal/tableformat"
 )
 
@@ -32,11 +34,24 @@ func (app *App) render() error {
 		QueryPanel(app, queryView)
 	}
 
+	completionView, _ := app.gui.View(completionViewName)
+	if completionView != nil && app.completion.Open {
+		CompletionList(app, completionView)
+	}
+
 	modalView, _ := app.gui.View(modalViewName)
 	if modalView != nil && app.modalOpen {
 		Modal(app, modalView)
 	}
 
+	historySearchView, _ := app.gui.View(historySearchViewName)
+	historyListView, _ := app.gui.View(historyListViewName)
+	historyPreviewView, _ := app.gui.View(historyPreviewViewName)
+	if historySearchView != nil && historyListView != nil && historyPreviewView != nil && app.historyBrowser.Open {
+		HistoryBrowser(app, historySearchView, historyListView, historyPreviewView)
+		setViewFocusStyle(historySearchView, app.focusArea == focusHistory)
+	}
+
 	app.applyFocusStyles(sidebarView, rowsHeaderView, rowsBodyView, queryView, modalView)
 	app.applyModalDimStyles(sidebarView, rowsHeaderView, rowsBodyView, queryView, statusView)
 
@@ -61,6 +76,7 @@ func (app *App) render() error {
 	}
 
 	app.syncOffsets(viewRowCount, viewportHeight)
+	app.clampCursor(viewRowCount)
 
 	viewOffset := app.currentOffset()
 
@@ -99,7 +115,7 @@ func (app *App) applyFocusStyles(sidebarView *gocui.View, rowsHeaderView *gocui.
 }
 
 func (app *App) applyModalDimStyles(sidebarView *gocui.View, rowsHeaderView *gocui.View, rowsBodyView *gocui.View, queryView *gocui.View, statusView *gocui.View) {
-	if app.modalOpen {
+	if app.overlayOpen() {
 		setViewDimStyle(sidebarView)
 		setViewDimStyle(rowsHeaderView)
 		setViewDimStyle(rowsBodyView)
@@ -152,16 +168,34 @@ func resetViewDimStyle(view *gocui.View) {
 }
 
 func (app *App) buildTableView() (tableformat.TableRender, bool) {
+	if app.viewMode == viewText {
+		body := app.textState.Body
+		if app.textState.Styled != "" {
+			body = app.textState.Styled
+		}
+
+		return tableformat.TableRender{
+			Header:         "",
+			Body:           body,
+			Width:          measureMessageWidth(app.textState.Body),
+			RowCount:       app.textLineCount(),
+			ColumnWidths:   nil,
+			SeparatorWidth: 0,
+		}, false
+	}
+
 	isQueryMode := app.viewMode == viewQuery
 
-	visibleRows := app.tableState.Rows
+	visibleRows := app.displayTableRows()
 	visibleColumns := app.tableState.Columns
 	visibleError := app.tableState.Error
+	cellStyle := app.pendingCellStyle
 
 	if isQueryMode {
 		visibleRows = app.queryState.AllRows
 		visibleColumns = app.queryState.Columns
 		visibleError = app.queryState.Error
+		cellStyle = nil
 	}
 
 	if visibleError != "" {
@@ -202,10 +236,22 @@ func (app *App) buildTableView() (tableformat.TableRender, bool) {
 		}, true
 	}
 
+	if len(app.pendingChanges) == 0 {
+		cellStyle = nil
+	}
+
+	columnLabels := []string(nil)
+	if !isQueryMode {
+		columnLabels = app.sortColumnLabels()
+	}
+
 	tableView := tableformat.ComputeTable(tableformat.ComputeTableConfig{
-		Columns: visibleColumns,
-		Rows:    visibleRows,
-		MaxRows: 0,
+		Columns:      visibleColumns,
+		ColumnLabels: columnLabels,
+		Rows:         visibleRows,
+		MaxRows:      0,
+		CellStyle:    app.cursorCellStyle(cellStyle),
+		Highlight:    app.searchTerm,
 	})
 
 	return tableView, false
@@ -213,10 +259,14 @@ func (app *App) buildTableView() (tableformat.TableRender, bool) {
 
 func (app *App) currentRowCount() int {
 	if app.viewMode == viewQuery {
-		return len(app.queryState.AllRows)
+		return app.queryState.RowCount
+	}
+
+	if app.viewMode == viewText {
+		return app.textLineCount()
 	}
 
-	return app.tableState.TotalRows
+	return app.tableState.TotalRows + len(app.pendingInsertIndexes())
 }
 
 func (app *App) currentOffset() int {
@@ -224,6 +274,10 @@ func (app *App) currentOffset() int {
 		return app.queryState.Offset
 	}
 
+	if app.viewMode == viewText {
+		return app.textState.Offset
+	}
+
 	return app.tableState.Offset
 }
 
@@ -231,6 +285,13 @@ func (app *App) syncOffsets(viewRowCount int, viewportRows int) {
 	if app.viewMode == viewQuery {
 		maxOffset := max(0, viewRowCount-viewportRows)
 		app.queryState.Offset = clampInt(app.queryState.Offset, 0, maxOffset)
+		app.ensureQueryWindow(viewportRows)
+		return
+	}
+
+	if app.viewMode == viewText {
+		maxOffset := max(0, viewRowCount-viewportRows)
+		app.textState.Offset = clampInt(app.textState.Offset, 0, maxOffset)
 		return
 	}
 
@@ -240,7 +301,7 @@ func (app *App) syncOffsets(viewRowCount int, viewportRows int) {
 		return
 	}
 
-	maxOffset := max(0, app.tableState.TotalRows-viewportRows)
+	maxOffset := max(0, viewRowCount-viewportRows)
 	nextOffset := clampInt(app.tableState.Offset, 0, maxOffset)
 	nextBufferStart := app.tableState.BufferStart
 	bufferEnd := nextBufferStart + app.tableState.BufferSize
@@ -271,12 +332,12 @@ func (app *App) updateSidebarScroll(viewHeight int) {
 		return
 	}
 
-	if app.selectedTableIndex < app.sidebarScroll {
-		app.sidebarScroll = app.selectedTableIndex
+	if app.sidebarIndex < app.sidebarScroll {
+		app.sidebarScroll = app.sidebarIndex
 	}
 
-	if app.selectedTableIndex >= app.sidebarScroll+viewHeight {
-		app.sidebarScroll = app.selectedTableIndex - viewHeight + 1
+	if app.sidebarIndex >= app.sidebarScroll+viewHeight {
+		app.sidebarScroll = app.sidebarIndex - viewHeight + 1
 	}
 
 	if app.sidebarScroll < 0 {
@@ -286,32 +347,89 @@ func (app *App) updateSidebarScroll(viewHeight int) {
 
 func (app *App) getRowsTitle() string {
 	if app.viewMode == viewQuery {
-		return truncateTitle(app.queryState.SQL)
+		title := truncateTitle(app.queryState.SQL)
+		if tabs := app.queryTabsLabel(); tabs != "" {
+			title = "Tabs " + tabs + " | " + title
+		}
+		return title
+	}
+
+	if app.viewMode == viewText {
+		return app.textState.Title
 	}
 
-	if app.tableState.Name != "" {
-		return app.tableState.Name
+	if app.tableState.Name == "" {
+		return "Rows"
+	}
+
+	title := app.tableState.Name
+	if app.tableState.ObjectType == db.ObjectView {
+		title += " (view)"
+	}
+
+	if app.tableState.Filter.Where != "" {
+		title += " [" + app.tableState.Filter.Where + "]"
+	}
+
+	return title
+}
+
+func (app *App) textLineCount() int {
+	if app.textState.Body == "" {
+		return 0
 	}
 
-	return "Rows"
+	return strings.Count(app.textState.Body, "\n") + 1
+}
+
+func (app *App) setStatusMessage(message string) {
+	app.statusMessage = message
+	app.statusMessageAt = time.Now()
 }
 
 func (app *App) buildStatusLeft() string {
+	if app.exportState.Running {
+		return app.exportProgress()
+	}
+
+	if app.importState.Running {
+		return app.importProgress()
+	}
+
+	if app.statusMessage != "" && time.Since(app.statusMessageAt) < statusMessageTTL {
+		return app.statusMessage
+	}
+
 	if app.viewMode == viewQuery {
 		if app.queryState.Error != "" {
 			return "Error: " + app.queryState.Error
 		}
 
 		if app.queryState.Running {
-			return "Running query..."
+			elapsed := time.Since(app.queryState.StartedAt)
+			frame := spinnerFrames[int(elapsed/spinnerInterval)%len(spinnerFrames)]
+			return fmt.Sprintf("Running query %s %s", frame, formatDuration(elapsed))
+		}
+
+		count := app.queryState.RowCount
+		duration := formatDuration(app.queryState.Duration)
+		if app.queryState.Done {
+			return fmt.Sprintf("Query rows %d in %s", count, duration)
 		}
 
-		count := len(app.queryState.AllRows)
 		if app.queryState.Truncated {
-			return fmt.Sprintf("Query rows %d (truncated at %d)", count, queryRowCap)
+			return fmt.Sprintf("Query rows %d+ (stopped) in %s", count, duration)
 		}
 
-		return fmt.Sprintf("Query rows %d", count)
+		if app.queryState.Fetching {
+			return fmt.Sprintf("Query rows %d+ in %s  fetching...", count, duration)
+		}
+
+		return fmt.Sprintf("Query rows %d+ in %s", count, duration)
+	}
+
+	if app.viewMode == viewText {
+		return fmt.Sprintf("Lines %d", app.textLineCount())
 	}
 
 	if app.tableState.Error != "" {
@@ -323,26 +441,64 @@ func (app *App) buildStatusLeft() string {
 	}
 
 	showStart, showEnd := app.currentRowRange()
-	return fmt.Sprintf("Rows %d  Showing %d-%d", app.tableState.TotalRows, showStart, showEnd)
+	status := fmt.Sprintf("Rows %d  Showing %d-%d", app.tableState.TotalRows, showStart, showEnd)
+	if app.tableState.Filter.Where != "" {
+		status = fmt.Sprintf("Rows %d (filtered)  Showing %d-%d", app.tableState.TotalRows, showStart, showEnd)
+	}
+	if len(app.pendingChanges) > 0 {
+		status += fmt.Sprintf("  Pending %d", len(app.pendingChanges))
+	}
+
+	return status
 }
 
 func (app *App) buildStatusRight() string {
+	if app.searchCancel != nil {
+		return "Esc cancel search"
+	}
+
+	if app.exportState.Running {
+		return "Esc cancel export"
+	}
+
+	if app.importState.Running {
+		return "Esc cancel import"
+	}
+
 	if app.focusArea == focusSidebar {
-		return "Tab rows  Enter open  q quit"
+		return "Tab rows  Enter open  Space expand  i structure  I import  q quit"
 	}
 
 	if app.focusArea == focusRows {
-		return "Tab query  j/k scroll  h/l pan  q quit"
+		if app.writable && app.viewMode == viewTable {
+			return "e edit  o insert  d delete  w commit  U discard  g follow  b back  q quit"
+		}
+		return "hjkl move  Enter value  / search  s sort  f filter  = match  g follow  r refs  b back  E export  q quit"
+	}
+
+	if app.queryState.Running {
+		return "Esc cancel query"
 	}
 
 	if app.focusArea == focusQuery {
-		return "Enter run  Shift+Enter newline  Up/Down history  Tab tables  q quit"
+		return "Enter run statement  ^E run all  ^S save snippet  ^O snippets  Shift+Enter newline  Tab complete  Up/Down history  ^T/^W/^N/^P tabs  q quit"
+	}
+
+	if app.focusArea == focusHistory {
+		return "Enter load  Up/Down select  ^A this db/all  ^F pin  ^D delete  Esc close"
 	}
 
 	if app.focusArea == focusModal {
+		if len(app.modalItems) > 0 {
+			return "Enter open  j/k select  Esc close"
+		}
 		return "Esc close  j/k scroll"
 	}
 
+	if app.focusArea == focusPrompt {
+		return "Enter submit  Esc cancel"
+	}
+
 	return "Tab cycle  q quit"
 }
 
@@ -364,6 +520,14 @@ func (app *App) currentRowRange() (int, int) {
 	return showStart, showEnd
 }
 
+func formatDuration(duration time.Duration) string {
+	if duration < time.Second {
+		return fmt.Sprintf("%dms", duration.Milliseconds())
+	}
+
+	return fmt.Sprintf("%.1fs", duration.Seconds())
+}
+
 func renderStatusLine(width int, left string, right string) string {
 	if width <= 0 {
 		return ""
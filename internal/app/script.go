@@ -0,0 +1,301 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/awesome-gocui/gocui"
+
+	"squlito/internal/db"
+	"squlito/internal/sqlsyntax"
+	"squlito/internal/tableformat"
+)
+
+var scriptSummaryColumns = []string{"#", "statement", "result", "time"}
+
+type scriptOutcome struct {
+	Summaries  []StatementSummary
+	Cursor     *db.QueryCursor
+	Columns    []string
+	Rows       []db.SqliteRow
+	Truncated  bool
+	Failed     int
+	Err        error
+	RolledBack bool
+}
+
+func (app *App) runStatementAtCursor(view *gocui.View) error {
+	text, cursor := queryTextAtCursor(view)
+	statements := sqlsyntax.SplitStatements(text)
+	if len(statements) < 2 {
+		return app.runQuery(view.Buffer())
+	}
+
+	return app.runQuery(statements[sqlsyntax.StatementAt(statements, cursor)].Text)
+}
+
+func (app *App) runScript(sqlText string) error {
+	statements := sqlsyntax.SplitStatements(sqlText)
+	if len(statements) < 2 {
+		return app.runQuery(sqlText)
+	}
+
+	parameters, err := sqlsyntax.Parameters(sqlText)
+	if err != nil {
+		app.rejectQuery(sqlText, err.Error())
+		return nil
+	}
+	if len(parameters) > 0 {
+		app.setStatusMessage("Scripts cannot take parameters; run those statements one at a time with Enter")
+		return nil
+	}
+
+	tab := app.currentQueryTab()
+	state := &tab.State
+	app.viewMode = viewQuery
+	state.Offset = 0
+	state.CursorRow = 0
+	state.CursorCol = 0
+	discardQueryRows(tab)
+
+	trimmed := strings.TrimSpace(sqlText)
+	app.resetHistorySelection()
+	app.recordHistory(tab, trimmed, nil)
+
+	shown := -1
+	for index, statement := range statements {
+		if sqlsyntax.ReturnsRows(statement.Text) {
+			shown = index
+		}
+	}
+
+	startQueryState(state, trimmed)
+	if shown >= 0 {
+		state.SQL = statements[shown].Text
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tab.cancel = cancel
+	runID := tab.runID
+	dbConn := app.db
+
+	app.startSpinner()
+	go func() {
+		outcome := executeScript(ctx, dbConn, statements, shown)
+		app.gui.Update(func(gui *gocui.Gui) error {
+			app.finishScript(tab, runID, shown, outcome)
+			return app.render()
+		})
+	}()
+
+	return nil
+}
+
+func executeScript(ctx context.Context, dbConn *sql.DB, statements []sqlsyntax.Statement, shown int) (outcome scriptOutcome) {
+	outcome.Failed = -1
+	script, err := db.OpenScript(ctx, dbConn)
+	if err != nil {
+		outcome.Err = err
+		return outcome
+	}
+
+	released := false
+	defer func() {
+		if released {
+			return
+		}
+		outcome.RolledBack = script.InTransaction()
+		_ = script.Close()
+	}()
+
+	for index, statement := range statements {
+		if outcome.Err != nil {
+			outcome.Summaries = append(outcome.Summaries, StatementSummary{
+				SQL:      statement.Text,
+				Result:   "skipped",
+				Duration: 0,
+				Failed:   false,
+			})
+			continue
+		}
+
+		startedAt := time.Now()
+		result := ""
+		switch {
+		case index == shown && index == len(statements)-1 && !script.InTransaction():
+			result, err = streamScriptRows(ctx, script, statement.Text, &outcome)
+			released = outcome.Cursor != nil
+		case index == shown:
+			result, err = collectScriptRows(ctx, script, statement.Text, &outcome)
+		case sqlsyntax.ReturnsRows(statement.Text):
+			var count int
+			count, err = script.CountRows(ctx, statement.Text)
+			result = fmt.Sprintf("%d rows", count)
+		default:
+			var affected int64
+			affected, err = script.Exec(ctx, statement.Text)
+			result = describeAffectedRows(statement.Text, affected)
+		}
+
+		if err != nil {
+			result = describeQueryError(err)
+			outcome.Err = err
+			outcome.Failed = index
+		}
+
+		outcome.Summaries = append(outcome.Summaries, StatementSummary{
+			SQL:      statement.Text,
+			Result:   result,
+			Duration: time.Since(startedAt),
+			Failed:   err != nil,
+		})
+	}
+
+	return outcome
+}
+
+func streamScriptRows(ctx context.Context, script *db.Script, sqlText string, outcome *scriptOutcome) (string, error) {
+	cursor, err := script.Query(ctx, sqlText, true)
+	if err != nil {
+		return "", err
+	}
+
+	rows, err := cursor.Fetch(queryFetchSize)
+	if err != nil {
+		closeCursor(cursor)
+		outcome.Cursor = nil
+		return "", err
+	}
+
+	outcome.Cursor = cursor
+	outcome.Columns = cursor.Columns()
+	outcome.Rows = rows
+	return "rows shown in grid", nil
+}
+
+func collectScriptRows(ctx context.Context, script *db.Script, sqlText string, outcome *scriptOutcome) (string, error) {
+	cursor, err := script.Query(ctx, sqlText, false)
+	if err != nil {
+		return "", err
+	}
+	defer closeCursor(cursor)
+
+	rows, err := cursor.Fetch(queryWindowSize + 1)
+	if err != nil {
+		return "", err
+	}
+
+	outcome.Columns = cursor.Columns()
+	outcome.Truncated = len(rows) > queryWindowSize
+	outcome.Rows = rows[:min(len(rows), queryWindowSize)]
+	if outcome.Truncated {
+		return fmt.Sprintf("first %d rows shown in grid", len(outcome.Rows)), nil
+	}
+	return fmt.Sprintf("%d rows shown in grid", len(outcome.Rows)), nil
+}
+
+func (app *App) finishScript(tab *QueryTab, runID int, shown int, outcome scriptOutcome) {
+	if runID != tab.runID {
+		closeCursor(outcome.Cursor)
+		return
+	}
+
+	state := &tab.State
+	state.Running = false
+	state.Duration = time.Since(state.StartedAt)
+	state.Script = outcome.Summaries
+	app.stopSpinnerIfIdle()
+	defer app.recordHistoryResult(tab)
+
+	err := app.loadSchema()
+	if err != nil {
+		app.setStatusMessage("Reloading schema failed: " + err.Error())
+	}
+
+	status := fmt.Sprintf("Ran %d statements in %s; i shows the summary", len(outcome.Summaries), formatDuration(state.Duration))
+	if outcome.RolledBack {
+		status += "; open transaction rolled back"
+	}
+
+	if outcome.Err != nil {
+		closeCursor(outcome.Cursor)
+		state.AllRows = nil
+		state.Columns = nil
+		state.Error = fmt.Sprintf("Statement %d: %s", outcome.Failed+1, describeQueryError(outcome.Err))
+		app.setStatusMessage(status)
+		return
+	}
+
+	if shown < 0 {
+		state.AllRows = nil
+		state.Columns = nil
+		state.Done = true
+		app.showScriptSummary()
+		app.setStatusMessage(fmt.Sprintf("Ran %d statements in %s", len(outcome.Summaries), formatDuration(state.Duration)))
+		return
+	}
+
+	tab.cursor = outcome.Cursor
+	state.Columns = outcome.Columns
+	state.AllRows = outcome.Rows
+	state.WindowStart = 0
+	state.RowCount = len(outcome.Rows)
+	state.Truncated = outcome.Truncated
+	state.Done = outcome.Cursor == nil && !outcome.Truncated
+	finishCursorIfDone(tab)
+	app.setStatusMessage(status)
+}
+
+func (app *App) showScriptSummary() {
+	app.viewMode = viewText
+	app.textState = TextState{
+		Kind:   textScript,
+		Title:  fmt.Sprintf("Script: %d statements", len(app.queryState.Script)),
+		Body:   formatScriptSummary(app.queryState.Script),
+		Styled: "",
+		Offset: 0,
+	}
+}
+
+func formatScriptSummary(summaries []StatementSummary) string {
+	rows := []db.SqliteRow{}
+	for index, summary := range summaries {
+		statement := strings.Join(strings.Fields(summary.SQL), " ")
+		rows = append(rows, db.SqliteRow{
+			"#":         index + 1,
+			"statement": truncateLine(statement, titleMaxChars),
+			"result":    summary.Result,
+			"time":      formatDuration(summary.Duration),
+		})
+	}
+
+	table := tableformat.ComputeTable(tableformat.ComputeTableConfig{
+		Columns:      scriptSummaryColumns,
+		ColumnLabels: nil,
+		Rows:         rows,
+		MaxRows:      0,
+		CellStyle:    nil,
+		Highlight:    "",
+	})
+
+	return table.Header + "\n" + table.Body
+}
+
+func describeAffectedRows(sqlText string, affected int64) string {
+	for _, token := range sqlsyntax.Tokenize(sqlText) {
+		if token.Kind == sqlsyntax.TokenWhitespace || token.Kind == sqlsyntax.TokenComment {
+			continue
+		}
+
+		switch strings.ToUpper(token.Text) {
+		case "INSERT", "UPDATE", "DELETE", "REPLACE":
+			return fmt.Sprintf("%d rows affected", affected)
+		}
+		break
+	}
+
+	return "ok"
+}
@@ -0,0 +1,334 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+
+	"squlito/internal/db"
+	"squlito/internal/tableformat"
+)
+
+const (
+	searchPageSize  = 500
+	searchScanLimit = 200000
+)
+
+var errSearchStopped = errors.New("search stopped")
+
+// searchScan walks rows off the gocui thread. Table searches page through the
+// table; query searches read the tab's window and on-disk cache, and when those
+// run out they wait for the tab's next fetch and scan on from there.
+type searchScan struct {
+	app     *App
+	ctx     context.Context
+	id      int
+	term    string
+	columns []string
+	forward bool
+	scanned int
+
+	rowCount int
+	start    int
+	rows     []db.SqliteRow
+
+	page    *db.TablePageQuery
+	anchors []db.PageAnchor
+
+	tab         *QueryTab
+	runID       int
+	window      []db.SqliteRow
+	windowStart int
+	spill       *db.RowSpill
+}
+
+type searchMatch struct {
+	row   int
+	col   int
+	found bool
+}
+
+func (app *App) openSearch() error {
+	if app.viewMode == viewText {
+		return nil
+	}
+
+	return app.openPrompt("Search", app.searchTerm, func(value string) error {
+		app.searchTerm = value
+		if value == "" {
+			return nil
+		}
+
+		app.searchNext(true)
+		return nil
+	})
+}
+
+func (app *App) searchNext(forward bool) {
+	if app.viewMode == viewText {
+		return
+	}
+
+	app.cancelSearch()
+	term := strings.ToLower(app.searchTerm)
+	if term == "" {
+		app.setStatusMessage("No search term: press / to search")
+		return
+	}
+
+	columns := app.currentColumns()
+	rowCount := app.currentRowCount()
+	if len(columns) == 0 || rowCount == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	app.searchCancel = cancel
+	app.searchID++
+	scan := &searchScan{
+		app:         app,
+		ctx:         ctx,
+		id:          app.searchID,
+		term:        term,
+		columns:     slices.Clone(columns),
+		forward:     forward,
+		scanned:     0,
+		rowCount:    rowCount,
+		start:       0,
+		rows:        nil,
+		page:        nil,
+		anchors:     nil,
+		tab:         nil,
+		runID:       0,
+		window:      nil,
+		windowStart: 0,
+		spill:       nil,
+	}
+	if app.viewMode == viewQuery {
+		tab := app.currentQueryTab()
+		scan.tab = tab
+		scan.runID = tab.runID
+		scan.snapshotQuery()
+	} else {
+		page := app.tablePageQuery(0, searchPageSize, false)
+		scan.page = &page
+		scan.anchors = app.tableState.Anchors
+	}
+
+	cursorRow, cursorCol := app.cursorPosition()
+	app.setStatusMessage("Searching for " + app.searchTerm + "...")
+	app.runSearch(scan, cursorRow, cursorCol)
+}
+
+func (app *App) runSearch(scan *searchScan, row int, col int) {
+	go func() {
+		match, err := scan.find(row, col)
+		app.gui.Update(func(gui *gocui.Gui) error {
+			app.finishSearch(scan, match, err)
+			return app.render()
+		})
+	}()
+}
+
+func (app *App) cancelSearch() bool {
+	if app.searchCancel == nil {
+		return false
+	}
+
+	app.searchCancel()
+	app.searchCancel = nil
+	app.searchWaiting = nil
+	app.searchID++
+	app.setStatusMessage("Search cancelled")
+	return true
+}
+
+func (app *App) finishSearch(scan *searchScan, match searchMatch, err error) {
+	if scan.id != app.searchID {
+		return
+	}
+
+	current := app.searchTargetCurrent(scan)
+	if current && err == nil && !match.found && scan.tab != nil && scan.forward && scan.tab.cursor != nil {
+		app.searchWaiting = scan
+		app.setStatusMessage(fmt.Sprintf("Searching for %s: %d rows fetched...", app.searchTerm, scan.tab.State.RowCount))
+		app.resumeSearch(scan.tab)
+		return
+	}
+
+	app.searchCancel()
+	app.searchCancel = nil
+	if !current {
+		app.setStatusMessage("Search abandoned: the rows changed")
+		return
+	}
+
+	if scan.tab == nil {
+		app.tableState.Anchors = db.MergeAnchors(app.tableState.Anchors, scan.anchors, tableAnchorLimit)
+	}
+
+	switch {
+	case errors.Is(err, errSearchStopped):
+		app.setStatusMessage(fmt.Sprintf("Search stopped after %d rows", scan.scanned))
+	case err != nil:
+		app.setStatusMessage("Search failed: " + err.Error())
+	case !match.found && scan.tab != nil && scan.tab.State.Truncated:
+		app.setStatusMessage("Pattern not found in the fetched rows: " + app.searchTerm)
+	case !match.found:
+		app.setStatusMessage("Pattern not found: " + app.searchTerm)
+	default:
+		app.setCursorPosition(match.row, match.col)
+		app.revealCursor()
+		app.setStatusMessage("/" + app.searchTerm)
+	}
+}
+
+func (app *App) searchTargetCurrent(scan *searchScan) bool {
+	if scan.tab != nil {
+		return app.viewMode == viewQuery && app.currentQueryTab() == scan.tab && scan.tab.runID == scan.runID
+	}
+
+	state := app.tableState
+	return app.viewMode == viewTable &&
+		state.Name == scan.page.Table &&
+		state.Filter.Where == scan.page.Where &&
+		slices.Equal(state.Sort, scan.page.OrderBy)
+}
+
+func (scan *searchScan) find(cursorRow int, cursorCol int) (searchMatch, error) {
+	step := 1
+	if !scan.forward {
+		step = -1
+	}
+
+	col := cursorCol + step
+	for row := cursorRow; row >= 0; row += step {
+		if scan.scanned >= searchScanLimit {
+			return searchMatch{row: 0, col: 0, found: false}, errSearchStopped
+		}
+
+		values, ok, err := scan.row(row)
+		if err != nil || !ok {
+			return searchMatch{row: 0, col: 0, found: false}, err
+		}
+		scan.scanned++
+
+		for ; col >= 0 && col < len(scan.columns); col += step {
+			value := tableformat.FormatCell(values[scan.columns[col]])
+			if strings.Contains(strings.ToLower(value), scan.term) {
+				return searchMatch{row: row, col: col, found: true}, nil
+			}
+		}
+
+		col = 0
+		if !scan.forward {
+			col = len(scan.columns) - 1
+		}
+	}
+
+	return searchMatch{row: 0, col: 0, found: false}, nil
+}
+
+func (scan *searchScan) row(index int) (db.SqliteRow, bool, error) {
+	offset := index - scan.start
+	if offset >= 0 && offset < len(scan.rows) {
+		return scan.rows[offset], true, nil
+	}
+
+	err := scan.ctx.Err()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if index >= scan.rowCount {
+		return nil, false, nil
+	}
+
+	start := index
+	if !scan.forward {
+		start = max(0, index-searchPageSize+1)
+	}
+
+	err = scan.load(start)
+	if err != nil {
+		return nil, false, err
+	}
+
+	offset = index - scan.start
+	if offset < 0 || offset >= len(scan.rows) {
+		return nil, false, nil
+	}
+
+	return scan.rows[offset], true, nil
+}
+
+func (scan *searchScan) load(start int) error {
+	if scan.tab == nil {
+		query := *scan.page
+		query.Offset = start
+		query.Anchors = scan.anchors
+		page, err := db.QueryTablePage(scan.app.db, query)
+		if err != nil {
+			return err
+		}
+
+		scan.anchors = db.MergeAnchors(scan.anchors, page.Anchors, tableAnchorLimit)
+		scan.start = page.Offset
+		scan.rows = page.Rows
+		return nil
+	}
+
+	windowEnd := scan.windowStart + len(scan.window)
+	if start >= scan.windowStart && start < windowEnd || scan.spill == nil {
+		scan.start = scan.windowStart
+		scan.rows = scan.window
+		return nil
+	}
+
+	rows, err := scan.spill.Read(start, searchPageSize)
+	if err != nil {
+		return err
+	}
+
+	scan.start = start
+	scan.rows = rows
+	return nil
+}
+
+func (scan *searchScan) snapshotQuery() {
+	state := &scan.tab.State
+	scan.rowCount = state.RowCount
+	scan.window = state.AllRows
+	scan.windowStart = state.WindowStart
+	scan.spill = scan.tab.spill
+	scan.start = scan.windowStart
+	scan.rows = scan.window
+}
+
+// resumeSearch is called whenever a fetch or window reload for tab settles. A
+// query search waiting for more rows scans the new ones, asks for the next
+// batch, or finishes once the cursor is exhausted or the query has changed.
+func (app *App) resumeSearch(tab *QueryTab) {
+	scan := app.searchWaiting
+	if scan == nil || scan.tab != tab || tab.State.Fetching {
+		return
+	}
+
+	state := &tab.State
+	switch {
+	case tab.runID == scan.runID && state.RowCount > scan.rowCount:
+		app.searchWaiting = nil
+		from := scan.rowCount
+		scan.snapshotQuery()
+		app.runSearch(scan, from, -1)
+	case tab.runID == scan.runID && tab.cursor != nil:
+		app.fetchMoreQueryRows(tab)
+	default:
+		app.searchWaiting = nil
+		app.finishSearch(scan, searchMatch{row: 0, col: 0, found: false}, nil)
+	}
+}
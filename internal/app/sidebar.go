@@ -0,0 +1,287 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"squlito/internal/db"
+	"squlito/internal/sqlsyntax"
+)
+
+type sidebarItemKind string
+
+const (
+	sidebarGroup  sidebarItemKind = "group"
+	sidebarObject sidebarItemKind = "object"
+)
+
+type sidebarItem struct {
+	Kind       sidebarItemKind
+	Key        string
+	Label      string
+	Depth      int
+	Expandable bool
+	Expanded   bool
+	Object     db.SchemaObject
+}
+
+type sidebarGroupSpec struct {
+	key        string
+	title      string
+	objectType string
+}
+
+var sidebarGroups = []sidebarGroupSpec{
+	{key: "group:tables", title: "Tables", objectType: db.ObjectTable},
+	{key: "group:views", title: "Views", objectType: db.ObjectView},
+	{key: "group:virtual", title: "Virtual tables", objectType: db.ObjectVirtualTable},
+}
+
+func (app *App) loadSchema() error {
+	objects, err := db.ListSchemaObjects(app.db)
+	if err != nil {
+		return err
+	}
+
+	tables := []db.SqliteTable{}
+	for _, group := range sidebarGroups {
+		for _, object := range objects {
+			if object.Type == group.objectType {
+				tables = append(tables, db.SqliteTable{Name: object.Name, Type: object.Type})
+			}
+		}
+	}
+
+	app.schema = objects
+	app.tables = tables
+	app.completionColumns = map[string][]string{}
+	// Schema reloads follow imports and scripts, which may have changed the
+	// rows behind the cached row count too.
+	app.tableState.Stale = true
+	return nil
+}
+
+func (app *App) sidebarItems() []sidebarItem {
+	items := []sidebarItem{}
+
+	for _, group := range sidebarGroups {
+		members := app.schemaObjectsOfType(group.objectType)
+		if len(members) == 0 {
+			continue
+		}
+
+		groupExpanded := !app.sidebarCollapsed[group.key]
+		items = append(items, sidebarItem{
+			Kind:       sidebarGroup,
+			Key:        group.key,
+			Label:      fmt.Sprintf("%s (%d)", group.title, len(members)),
+			Depth:      0,
+			Expandable: true,
+			Expanded:   groupExpanded,
+			Object:     db.SchemaObject{Name: "", Type: "", TableName: "", SQL: ""},
+		})
+		if !groupExpanded {
+			continue
+		}
+
+		for _, object := range members {
+			children := app.schemaChildren(object.Name)
+			key := "object:" + object.Name
+			expanded := app.sidebarExpanded[key]
+			items = append(items, sidebarItem{
+				Kind:       sidebarObject,
+				Key:        key,
+				Label:      object.Name,
+				Depth:      1,
+				Expandable: len(children) > 0,
+				Expanded:   expanded,
+				Object:     object,
+			})
+			if !expanded {
+				continue
+			}
+
+			for _, child := range children {
+				items = append(items, sidebarItem{
+					Kind:       sidebarObject,
+					Key:        "object:" + child.Name,
+					Label:      schemaChildPrefix(child) + child.Name,
+					Depth:      2,
+					Expandable: false,
+					Expanded:   false,
+					Object:     child,
+				})
+			}
+		}
+	}
+
+	return items
+}
+
+func (app *App) schemaObjectsOfType(objectType string) []db.SchemaObject {
+	objects := []db.SchemaObject{}
+	for _, object := range app.schema {
+		if object.Type == objectType {
+			objects = append(objects, object)
+		}
+	}
+	return objects
+}
+
+func (app *App) schemaChildren(tableName string) []db.SchemaObject {
+	children := []db.SchemaObject{}
+	for _, objectType := range []string{db.ObjectIndex, db.ObjectTrigger} {
+		for _, object := range app.schema {
+			if object.Type == objectType && object.TableName == tableName {
+				children = append(children, object)
+			}
+		}
+	}
+	return children
+}
+
+func (app *App) selectSidebarItem(index int) error {
+	items := app.sidebarItems()
+	if len(items) == 0 {
+		return nil
+	}
+
+	index = clampInt(index, 0, len(items)-1)
+	app.sidebarIndex = index
+	item := items[index]
+	if item.Kind != sidebarObject {
+		return nil
+	}
+
+	if isBrowsableObject(item.Object) {
+		return app.selectTable(item.Object)
+	}
+
+	app.showSchemaDDL(item.Object)
+	return nil
+}
+
+func (app *App) selectFirstTable() error {
+	for index, item := range app.sidebarItems() {
+		if item.Kind == sidebarObject && isBrowsableObject(item.Object) {
+			return app.selectSidebarItem(index)
+		}
+	}
+
+	return nil
+}
+
+func (app *App) revealSidebarObject(name string) {
+	object, ok := app.schemaObject(name)
+	if !ok {
+		return
+	}
+
+	for _, group := range sidebarGroups {
+		if group.objectType == object.Type {
+			delete(app.sidebarCollapsed, group.key)
+		}
+	}
+
+	for index, item := range app.sidebarItems() {
+		if item.Kind == sidebarObject && item.Depth == 1 && item.Object.Name == name {
+			app.sidebarIndex = index
+			return
+		}
+	}
+}
+
+func (app *App) currentSidebarItem() (item sidebarItem, ok bool) {
+	items := app.sidebarItems()
+	if app.sidebarIndex < 0 || app.sidebarIndex >= len(items) {
+		return item, false
+	}
+
+	return items[app.sidebarIndex], true
+}
+
+func (app *App) setSidebarExpanded(expanded bool) bool {
+	item, ok := app.currentSidebarItem()
+	if !ok || !item.Expandable || item.Expanded == expanded {
+		return false
+	}
+
+	if item.Kind == sidebarGroup {
+		app.sidebarCollapsed[item.Key] = !expanded
+		return true
+	}
+
+	app.sidebarExpanded[item.Key] = expanded
+	return true
+}
+
+func (app *App) toggleSidebarItem() {
+	item, ok := app.currentSidebarItem()
+	if !ok {
+		return
+	}
+
+	app.setSidebarExpanded(!item.Expanded)
+}
+
+func (app *App) collapseSidebarItem() error {
+	if app.setSidebarExpanded(false) {
+		return nil
+	}
+
+	item, ok := app.currentSidebarItem()
+	if !ok || item.Depth == 0 {
+		return nil
+	}
+
+	items := app.sidebarItems()
+	for index := app.sidebarIndex - 1; index >= 0; index -= 1 {
+		if items[index].Depth < item.Depth {
+			return app.selectSidebarItem(index)
+		}
+	}
+
+	return nil
+}
+
+func (app *App) showSchemaDDL(object db.SchemaObject) {
+	body := strings.TrimSpace(object.SQL)
+	styled := sqlsyntax.Highlight(body)
+	if body == "" {
+		body = "(no SQL available)"
+		styled = body
+	}
+
+	app.viewMode = viewText
+	app.textState = TextState{
+		Kind:   textDDL,
+		Title:  fmt.Sprintf("%s %s on %s", object.Type, object.Name, object.TableName),
+		Body:   body,
+		Styled: styled,
+		Offset: 0,
+	}
+}
+
+func sidebarLine(item sidebarItem) string {
+	marker := "  "
+	if item.Expandable && item.Expanded {
+		marker = "▾ "
+	}
+	if item.Expandable && !item.Expanded {
+		marker = "▸ "
+	}
+
+	return strings.Repeat("  ", item.Depth) + marker + item.Label
+}
+
+func schemaChildPrefix(object db.SchemaObject) string {
+	if object.Type == db.ObjectTrigger {
+		return "tr "
+	}
+
+	return "ix "
+}
+
+func isBrowsableObject(object db.SchemaObject) bool {
+	return object.Type == db.ObjectTable || object.Type == db.ObjectView || object.Type == db.ObjectVirtualTable
+}
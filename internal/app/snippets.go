@@ -0,0 +1,140 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+
+	"squlito/internal/snippets"
+)
+
+func (app *App) saveSnippet(view *gocui.View) error {
+	sqlText := strings.TrimSpace(view.Buffer())
+	if sqlText == "" {
+		app.setStatusMessage("Query is empty")
+		return nil
+	}
+
+	if app.historyDB == nil {
+		app.setStatusMessage("Snippets are unavailable")
+		return nil
+	}
+
+	return app.openPrompt("Snippet name", "", func(name string) error {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			app.setStatusMessage("Snippet name is empty")
+			return nil
+		}
+
+		return app.openPrompt("Tags (comma separated, optional)", "", func(tags string) error {
+			return app.openPrompt("Save for db (this database) or global", "db", func(scope string) error {
+				database := app.historyDatabase
+				switch strings.ToLower(strings.TrimSpace(scope)) {
+				case "", "db", "database":
+				case "global":
+					database = ""
+				default:
+					app.setStatusMessage("Unknown scope " + scope + ": use db or global")
+					return nil
+				}
+
+				err := snippets.Save(app.historyDB, snippets.Snippet{
+					Name:     name,
+					SQL:      sqlText,
+					Tags:     snippets.ParseTags(tags),
+					Database: database,
+				})
+				if err != nil {
+					app.setStatusMessage("Saving snippet failed: " + err.Error())
+					return nil
+				}
+
+				app.setStatusMessage("Saved snippet " + name)
+				return nil
+			})
+		})
+	})
+}
+
+func (app *App) openSnippetPicker() error {
+	if app.overlayOpen() {
+		return nil
+	}
+
+	if app.historyDB == nil {
+		app.setStatusMessage("Snippets are unavailable")
+		return nil
+	}
+
+	library, err := snippets.List(app.historyDB, app.historyDatabase)
+	if err != nil {
+		app.setStatusMessage("Loading snippets failed: " + err.Error())
+		return nil
+	}
+
+	if len(library) == 0 {
+		app.setStatusMessage("No snippets yet; ^S in the query editor saves one")
+		return nil
+	}
+
+	items := []pickerItem{}
+	for _, snippet := range library {
+		items = append(items, pickerItem{
+			Label:    snippetLabel(snippet),
+			OnSelect: func() error { return app.insertSnippet(snippet) },
+			OnRun:    func() error { return app.runSnippet(snippet) },
+		})
+	}
+
+	return app.openPicker("Snippets: Enter insert  r run", items)
+}
+
+func (app *App) insertSnippet(snippet snippets.Snippet) error {
+	err := app.setFocus(focusQuery)
+	if err != nil {
+		return err
+	}
+
+	view, err := app.gui.View("query")
+	if err != nil {
+		return nil
+	}
+
+	app.resetHistorySelection()
+	for _, r := range snippet.SQL {
+		view.EditWrite(r)
+	}
+	highlightQueryView(view)
+	return nil
+}
+
+func (app *App) runSnippet(snippet snippets.Snippet) error {
+	err := app.setFocus(focusQuery)
+	if err != nil {
+		return err
+	}
+
+	view, err := app.gui.View("query")
+	if err != nil {
+		return nil
+	}
+
+	app.resetHistorySelection()
+	app.setQueryViewContent(view, snippet.SQL)
+	return app.runScript(snippet.SQL)
+}
+
+func snippetLabel(snippet snippets.Snippet) string {
+	label := snippet.Name
+	if len(snippet.Tags) > 0 {
+		label += "  [" + strings.Join(snippet.Tags, ", ") + "]"
+	}
+
+	scope := "global"
+	if snippet.Database != "" {
+		scope = "this database"
+	}
+	return fmt.Sprintf("%s  (%s)  %s", label, scope, truncateLine(strings.Join(strings.Fields(snippet.SQL), " "), titleMaxChars))
+}
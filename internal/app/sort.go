@@ -0,0 +1,75 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+
+	"squlito/internal/db"
+)
+
+func (app *App) toggleSort(additive bool) {
+	if !app.requireTableView("Sorting") {
+		return
+	}
+
+	_, col := app.cursorPosition()
+	if col >= len(app.tableState.Columns) {
+		return
+	}
+
+	column := app.tableState.Columns[col]
+	sortKeys := slices.Clone(app.tableState.Sort)
+	index := slices.IndexFunc(sortKeys, func(key db.SortKey) bool { return key.Column == column })
+
+	switch {
+	case index < 0 && additive:
+		sortKeys = append(sortKeys, db.SortKey{Column: column, Descending: false})
+	case index < 0:
+		sortKeys = []db.SortKey{{Column: column, Descending: false}}
+	case !sortKeys[index].Descending && additive:
+		sortKeys[index].Descending = true
+	case !sortKeys[index].Descending:
+		sortKeys = []db.SortKey{{Column: column, Descending: true}}
+	case additive:
+		sortKeys = slices.Delete(sortKeys, index, index+1)
+	default:
+		sortKeys = nil
+	}
+
+	app.applySort(sortKeys)
+}
+
+func (app *App) applySort(sortKeys []db.SortKey) {
+	app.tableState.Sort = sortKeys
+	app.tableState.Offset = 0
+	app.tableState.BufferStart = 0
+	app.tableState.CursorRow = 0
+	app.tableState.Anchors = nil
+	_ = app.reloadTableBuffer()
+}
+
+func (app *App) sortColumnLabels() []string {
+	if len(app.tableState.Sort) == 0 {
+		return nil
+	}
+
+	labels := slices.Clone(app.tableState.Columns)
+	for position, key := range app.tableState.Sort {
+		index := slices.Index(app.tableState.Columns, key.Column)
+		if index < 0 {
+			continue
+		}
+
+		marker := "^"
+		if key.Descending {
+			marker = "v"
+		}
+		if len(app.tableState.Sort) > 1 {
+			marker += fmt.Sprint(position + 1)
+		}
+
+		labels[index] += " " + marker
+	}
+
+	return labels
+}
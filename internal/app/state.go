@@ -1,27 +1,41 @@
 package app
 
 import (
+	"context"
 	"strings"
+	"time"
 
+	"squlito/internal/completion"
 	"squlito/internal/db"
+	"squlito/internal/history"
 )
 
 const (
-	bufferSize        = 200
-	scrollStepDivisor = 5
-	queryRowCap       = 10000
-	queryBoxHeight    = 7
-	historyLimit      = 200
-	sidebarWidthMin   = 22
-	sidebarWidthMax   = 40
-	sidebarWidthRatio = 0.28
-	rowsHeaderHeight  = 3
-	statusHeight      = 2
-	minimumRowsHeight = 3
-	minimumMainWidth  = 20
-	titleMaxChars     = 60
+	bufferSize          = 200
+	tableAnchorLimit    = 256
+	scrollStepDivisor   = 5
+	queryFetchSize      = 500
+	queryWindowSize     = 5000
+	queryPrefetchMargin = 200
+	queryBoxHeight      = 7
+	historyLimit        = 200
+	sidebarWidthMin     = 22
+	sidebarWidthMax     = 40
+	sidebarWidthRatio   = 0.28
+	rowsHeaderHeight    = 3
+	statusHeight        = 2
+	minimumRowsHeight   = 3
+	minimumMainWidth    = 20
+	titleMaxChars       = 60
+	statusMessageTTL    = 4 * time.Second
+	spinnerInterval     = 100 * time.Millisecond
+	exportBatchSize     = 1000
+	progressBarWidth    = 20
+	completionMaxItems  = 8
 )
 
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
 type FocusArea string
 
 const (
@@ -29,6 +43,8 @@ const (
 	focusRows    FocusArea = "rows"
 	focusQuery   FocusArea = "query"
 	focusModal   FocusArea = "modal"
+	focusPrompt  FocusArea = "prompt"
+	focusHistory FocusArea = "history"
 )
 
 type ViewMode string
@@ -36,33 +52,148 @@ type ViewMode string
 const (
 	viewTable ViewMode = "table"
 	viewQuery ViewMode = "query"
+	viewText  ViewMode = "text"
 )
 
 type TableState struct {
-	Name        string
-	TotalRows   int
-	Offset      int
-	BufferStart int
-	BufferSize  int
-	Rows        []db.SqliteRow
+	Name           string
+	TotalRows      int
+	Offset         int
+	CursorRow      int
+	CursorCol      int
+	BufferStart    int
+	BufferSize     int
+	Rows           []db.SqliteRow
+	Columns        []string
+	ObjectType     string
+	KeyColumns     []string
+	PageKeyColumns []string
+	Anchors        []db.PageAnchor
+	Filter         TableFilter
+	Sort           []db.SortKey
+	Stale          bool
+	Error          string
+}
+
+type TableFilter struct {
+	Where string
+}
+
+type tableLocation struct {
+	Object    db.SchemaObject
+	Filter    TableFilter
+	Sort      []db.SortKey
+	Offset    int
+	CursorRow int
+	CursorCol int
+	ScrollX   int
+}
+
+type QueryState struct {
+	SQL         string
+	Args        []any
+	AllRows     []db.SqliteRow
 	Columns     []string
 	Error       string
+	Running     bool
+	Fetching    bool
+	Truncated   bool
+	Done        bool
+	Offset      int
+	CursorRow   int
+	CursorCol   int
+	WindowStart int
+	RowCount    int
+	StartedAt   time.Time
+	Duration    time.Duration
+	Script      []StatementSummary
 }
 
-type QueryState struct {
-	SQL       string
-	AllRows   []db.SqliteRow
-	Columns   []string
-	Error     string
+type StatementSummary struct {
+	SQL      string
+	Result   string
+	Duration time.Duration
+	Failed   bool
+}
+
+type ExportState struct {
 	Running   bool
-	Truncated bool
-	Offset    int
+	Path      string
+	Rows      int
+	Total     int
+	StartedAt time.Time
+}
+
+type ImportState struct {
+	Running    bool
+	Path       string
+	Table      string
+	Rows       int
+	BytesRead  int64
+	TotalBytes int64
+	StartedAt  time.Time
+}
+
+type QueryTab struct {
+	ID        int
+	Editor    string
+	State     QueryState
+	cursor    *db.QueryCursor
+	spill     *db.RowSpill
+	spilled   int
+	cancel    context.CancelFunc
+	runID     int
+	historyID int64
+}
+
+type gridCell struct {
+	RowIndex int
+	ColIndex int
+	Column   string
+	Value    db.SqliteValue
+	Width    int
+}
+
+type TextKind string
+
+const (
+	textDDL       TextKind = "ddl"
+	textScript    TextKind = "script"
+	textStructure TextKind = "structure"
+)
+
+type TextState struct {
+	Kind   TextKind
+	Title  string
+	Body   string
+	Styled string
+	Offset int
+}
+
+type CompletionState struct {
+	Open   bool
+	Prefix string
+	Items  []completion.Item
+	Index  int
+	Scroll int
+}
+
+type HistoryBrowserState struct {
+	Open      bool
+	Query     string
+	All       []history.Entry
+	Matches   []history.Entry
+	Index     int
+	Scroll    int
+	PrevFocus FocusArea
 }
 
-type QueryHistoryEntry struct {
-	ID        int64
-	SQL       string
-	CreatedAt string
+type PromptState struct {
+	Open      bool
+	Title     string
+	Value     string
+	PrevFocus FocusArea
+	OnSubmit  func(value string) error
 }
 
 type ScrollState struct {
@@ -0,0 +1,161 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"squlito/internal/db"
+	"squlito/internal/sqlsyntax"
+	"squlito/internal/tableformat"
+)
+
+var structureColumns = []string{"#", "name", "type", "null", "default", "pk", "extra"}
+
+func (app *App) toggleStructure() {
+	if app.viewMode == viewText && app.textState.Kind == textStructure {
+		app.viewMode = viewTable
+		return
+	}
+
+	if app.viewMode == viewText && app.textState.Kind == textScript {
+		app.viewMode = viewQuery
+		return
+	}
+
+	if app.viewMode == viewQuery && len(app.queryState.Script) > 0 {
+		app.showScriptSummary()
+		return
+	}
+
+	if app.tableState.Name == "" {
+		app.setStatusMessage("No table selected")
+		return
+	}
+
+	structure, err := db.GetTableStructure(app.db, app.tableState.Name)
+	if err != nil {
+		app.setStatusMessage("Loading structure failed: " + err.Error())
+		return
+	}
+
+	app.viewMode = viewText
+	app.textState = TextState{
+		Kind:   textStructure,
+		Title:  "Structure: " + app.tableState.Name,
+		Body:   formatStructure(structure, false),
+		Styled: formatStructure(structure, true),
+		Offset: 0,
+	}
+}
+
+func formatStructure(structure db.TableStructure, highlight bool) string {
+	rows := []db.SqliteRow{}
+	for _, column := range structure.Columns {
+		nullable := "yes"
+		if column.NotNull == 1 {
+			nullable = "no"
+		}
+
+		defaultValue := ""
+		if column.DefaultValue.Valid {
+			defaultValue = column.DefaultValue.String
+		}
+
+		primaryKey := ""
+		if column.PrimaryKey > 0 {
+			primaryKey = fmt.Sprint(column.PrimaryKey)
+		}
+
+		rows = append(rows, db.SqliteRow{
+			"#":       column.Cid,
+			"name":    column.Name,
+			"type":    column.Type,
+			"null":    nullable,
+			"default": defaultValue,
+			"pk":      primaryKey,
+			"extra":   describeHiddenColumn(column.Hidden),
+		})
+	}
+
+	table := tableformat.ComputeTable(tableformat.ComputeTableConfig{
+		Columns:      structureColumns,
+		ColumnLabels: nil,
+		Rows:         rows,
+		MaxRows:      0,
+		CellStyle:    nil,
+		Highlight:    "",
+	})
+
+	lines := []string{fmt.Sprintf("Columns (%d)", len(structure.Columns)), "  " + table.Header}
+	for line := range strings.SplitSeq(table.Body, "\n") {
+		lines = append(lines, "  "+line)
+	}
+
+	lines = append(lines, "", fmt.Sprintf("Foreign keys (%d)", len(structure.ForeignKeys)))
+	for _, foreignKey := range structure.ForeignKeys {
+		lines = append(lines, "  "+formatForeignKey(foreignKey))
+	}
+
+	lines = append(lines, "", fmt.Sprintf("Indexes (%d)", len(structure.Indexes)))
+	for _, index := range structure.Indexes {
+		lines = append(lines, "  "+formatIndex(index))
+	}
+
+	lines = append(lines, "", "SQL")
+	sqlText := strings.TrimSpace(structure.SQL)
+	if sqlText == "" {
+		sqlText = "(no SQL available)"
+	} else if highlight {
+		sqlText = sqlsyntax.Highlight(sqlText)
+	}
+	for line := range strings.SplitSeq(sqlText, "\n") {
+		lines = append(lines, "  "+line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func formatForeignKey(foreignKey db.ForeignKey) string {
+	line := fmt.Sprintf("(%s) -> %s(%s)", strings.Join(foreignKey.From, ", "), foreignKey.Table, strings.Join(foreignKey.To, ", "))
+	if foreignKey.OnUpdate != "" && foreignKey.OnUpdate != "NO ACTION" {
+		line += " ON UPDATE " + foreignKey.OnUpdate
+	}
+	if foreignKey.OnDelete != "" && foreignKey.OnDelete != "NO ACTION" {
+		line += " ON DELETE " + foreignKey.OnDelete
+	}
+
+	return line
+}
+
+func formatIndex(index db.TableIndex) string {
+	parts := []string{index.Name}
+	if index.Unique {
+		parts = append(parts, "UNIQUE")
+	}
+
+	parts = append(parts, "("+strings.Join(index.Columns, ", ")+")")
+	if index.Origin == "pk" {
+		parts = append(parts, "primary key")
+	}
+	if index.Origin == "u" {
+		parts = append(parts, "unique constraint")
+	}
+	if index.Partial {
+		parts = append(parts, "partial")
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func describeHiddenColumn(hidden int) string {
+	switch hidden {
+	case db.ColumnHidden:
+		return "hidden"
+	case db.ColumnGeneratedVirtual:
+		return "generated (virtual)"
+	case db.ColumnGeneratedStored:
+		return "generated (stored)"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,125 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+func newQueryTab(id int) *QueryTab {
+	return &QueryTab{
+		ID:     id,
+		Editor: "",
+		State: QueryState{
+			SQL:         "",
+			Args:        nil,
+			AllRows:     nil,
+			Columns:     nil,
+			Error:       "",
+			Running:     false,
+			Fetching:    false,
+			Truncated:   false,
+			Done:        false,
+			Offset:      0,
+			CursorRow:   0,
+			CursorCol:   0,
+			WindowStart: 0,
+			RowCount:    0,
+			StartedAt:   time.Time{},
+			Duration:    0,
+			Script:      nil,
+		},
+		cursor:    nil,
+		spill:     nil,
+		spilled:   0,
+		cancel:    nil,
+		runID:     0,
+		historyID: 0,
+	}
+}
+
+func (app *App) currentQueryTab() *QueryTab {
+	return app.queryTabs[app.activeTab]
+}
+
+func (app *App) openQueryTab() error {
+	tab := newQueryTab(app.nextTabID)
+	app.nextTabID += 1
+	app.queryTabs = append(app.queryTabs, tab)
+	return app.activateQueryTab(len(app.queryTabs) - 1)
+}
+
+func (app *App) closeQueryTab() error {
+	tab := app.currentQueryTab()
+	discardQueryRows(tab)
+	app.stopSpinnerIfIdle()
+
+	if len(app.queryTabs) == 1 {
+		replacement := newQueryTab(app.nextTabID)
+		app.nextTabID += 1
+		app.queryTabs[0] = replacement
+		app.queryState = &replacement.State
+		app.loadQueryEditor(replacement)
+		app.setStatusMessage(fmt.Sprintf("Closed query tab %d", tab.ID))
+		return nil
+	}
+
+	app.queryTabs = append(app.queryTabs[:app.activeTab], app.queryTabs[app.activeTab+1:]...)
+	next := min(app.activeTab, len(app.queryTabs)-1)
+	app.activeTab = next
+	app.queryState = &app.queryTabs[next].State
+	app.loadQueryEditor(app.queryTabs[next])
+	app.setStatusMessage(fmt.Sprintf("Closed query tab %d", tab.ID))
+	return nil
+}
+
+func (app *App) cycleQueryTab(delta int) error {
+	count := len(app.queryTabs)
+	return app.activateQueryTab(((app.activeTab+delta)%count + count) % count)
+}
+
+func (app *App) activateQueryTab(index int) error {
+	app.saveQueryEditor()
+	app.activeTab = index
+	tab := app.queryTabs[index]
+	app.queryState = &tab.State
+	app.loadQueryEditor(tab)
+	app.viewMode = viewQuery
+	return app.setFocus(focusQuery)
+}
+
+func (app *App) saveQueryEditor() {
+	view, err := app.gui.View("query")
+	if err != nil {
+		return
+	}
+
+	app.currentQueryTab().Editor = strings.TrimRight(view.Buffer(), "\n")
+}
+
+func (app *App) loadQueryEditor(tab *QueryTab) {
+	app.resetHistorySelection()
+	view, err := app.gui.View("query")
+	if err != nil {
+		return
+	}
+
+	app.setQueryViewContent(view, tab.Editor)
+}
+
+func (app *App) queryTabsLabel() string {
+	if len(app.queryTabs) < 2 {
+		return ""
+	}
+
+	labels := []string{}
+	for index, tab := range app.queryTabs {
+		label := fmt.Sprint(tab.ID)
+		if index == app.activeTab {
+			label = "[" + label + "]"
+		}
+		labels = append(labels, label)
+	}
+
+	return strings.Join(labels, " ")
+}
@@ -0,0 +1,389 @@
+package completion
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"squlito/internal/sqlsyntax"
+)
+
+type Kind string
+
+const (
+	KindColumn   Kind = "column"
+	KindTable    Kind = "table"
+	KindFunction Kind = "function"
+	KindKeyword  Kind = "keyword"
+)
+
+type Item struct {
+	Text string
+	Kind Kind
+}
+
+type Schema struct {
+	Tables  []string
+	Columns func(table string) []string
+}
+
+type Result struct {
+	Prefix    string
+	Qualifier string
+	Items     []Item
+}
+
+var tableKeywords = map[string]bool{
+	"FROM":   true,
+	"JOIN":   true,
+	"INTO":   true,
+	"UPDATE": true,
+	"TABLE":  true,
+}
+
+func (item Item) Insert() string {
+	switch item.Kind {
+	case KindFunction:
+		return item.Text + "("
+	case KindTable, KindColumn:
+		return QuoteIdentifier(item.Text)
+	default:
+		return item.Text
+	}
+}
+
+func Complete(text string, cursor int, schema Schema) Result {
+	cursor = min(max(cursor, 0), len(text))
+	before := text[:cursor]
+	if insideLiteral(before) {
+		return Result{Prefix: "", Qualifier: "", Items: nil}
+	}
+
+	tokens := currentStatement(significantTokens(text), cursor)
+
+	prefixStart := len(before)
+	for prefixStart > 0 {
+		r, size := utf8.DecodeLastRuneInString(before[:prefixStart])
+		if !sqlsyntax.IsIdentifierPart(r) {
+			break
+		}
+		prefixStart -= size
+	}
+
+	result := Result{
+		Prefix:    before[prefixStart:],
+		Qualifier: "",
+		Items:     nil,
+	}
+
+	if strings.HasSuffix(before[:prefixStart], ".") {
+		result.Qualifier = qualifierBefore(tokens, prefixStart-1)
+		if result.Qualifier == "" {
+			return result
+		}
+
+		table := resolveTable(result.Qualifier, tableReferences(tokens), schema.Tables)
+		result.Items = rank(result.Prefix, columnItems(schema, []string{table}))
+		return result
+	}
+
+	if tableKeywords[previousWord(tokens, prefixStart)] {
+		result.Items = rank(result.Prefix, tableItems(schema))
+		return result
+	}
+
+	referenced := []string{}
+	for _, reference := range tableReferences(tokens) {
+		table := resolveTable(reference.table, nil, schema.Tables)
+		if !slices.Contains(referenced, table) {
+			referenced = append(referenced, table)
+		}
+	}
+
+	candidates := columnItems(schema, referenced)
+	candidates = append(candidates, tableItems(schema)...)
+	candidates = append(candidates, caseItems(result.Prefix, Functions, KindFunction)...)
+	candidates = append(candidates, caseItems(result.Prefix, sqlsyntax.Keywords, KindKeyword)...)
+	result.Items = rank(result.Prefix, candidates)
+	return result
+}
+
+func Match(pattern string, text string) (score int, ok bool) {
+	if pattern == "" {
+		return 0, true
+	}
+
+	patternRunes := []rune(strings.ToLower(pattern))
+	textRunes := []rune(strings.ToLower(text))
+	patternIndex := 0
+	previous := -2
+	for textIndex, r := range textRunes {
+		if patternIndex == len(patternRunes) {
+			break
+		}
+		if r != patternRunes[patternIndex] {
+			continue
+		}
+
+		score += 1
+		if textIndex == previous+1 {
+			score += 5
+		}
+		if textIndex == 0 || textRunes[textIndex-1] == '_' {
+			score += 10
+		}
+		previous = textIndex
+		patternIndex += 1
+	}
+
+	if patternIndex < len(patternRunes) {
+		return 0, false
+	}
+
+	if strings.HasPrefix(string(textRunes), string(patternRunes)) {
+		score += 100
+	}
+
+	return score - (len(textRunes) - len(patternRunes)), true
+}
+
+func QuoteIdentifier(name string) string {
+	if isPlainIdentifier(name) && !sqlsyntax.IsKeyword(name) {
+		return name
+	}
+
+	return "\"" + strings.ReplaceAll(name, "\"", "\"\"") + "\""
+}
+
+func rank(prefix string, candidates []Item) []Item {
+	type scoredItem struct {
+		item  Item
+		score int
+	}
+
+	scored := []scoredItem{}
+	seen := map[Item]bool{}
+	for _, candidate := range candidates {
+		if seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+
+		score, ok := Match(prefix, candidate.Text)
+		if !ok {
+			continue
+		}
+		scored = append(scored, scoredItem{item: candidate, score: score})
+	}
+
+	if prefix != "" {
+		sort.SliceStable(scored, func(i int, j int) bool {
+			return scored[i].score > scored[j].score
+		})
+	}
+
+	items := make([]Item, 0, len(scored))
+	for _, entry := range scored {
+		items = append(items, entry.item)
+	}
+	return items
+}
+
+func tableItems(schema Schema) []Item {
+	items := []Item{}
+	for _, table := range schema.Tables {
+		items = append(items, Item{Text: table, Kind: KindTable})
+	}
+	return items
+}
+
+func columnItems(schema Schema, tables []string) []Item {
+	items := []Item{}
+	if schema.Columns == nil {
+		return items
+	}
+
+	for _, table := range tables {
+		for _, column := range schema.Columns(table) {
+			items = append(items, Item{Text: column, Kind: KindColumn})
+		}
+	}
+	return items
+}
+
+func caseItems(prefix string, words []string, kind Kind) []Item {
+	lower := prefix != "" && strings.ToLower(prefix) == prefix && strings.ToUpper(prefix) != prefix
+	items := make([]Item, 0, len(words))
+	for _, word := range words {
+		if lower {
+			word = strings.ToLower(word)
+		}
+		items = append(items, Item{Text: word, Kind: kind})
+	}
+	return items
+}
+
+type tableReference struct {
+	table string
+	alias string
+}
+
+func tableReferences(tokens []sqlsyntax.Token) []tableReference {
+	references := []tableReference{}
+	for index := 0; index < len(tokens); index++ {
+		keyword := strings.ToUpper(tokens[index].Text)
+		if tokens[index].Kind != sqlsyntax.TokenKeyword || !tableKeywords[keyword] || keyword == "TABLE" {
+			continue
+		}
+
+		next := index + 1
+		for next < len(tokens) && isWord(tokens[next]) {
+			reference := tableReference{table: identifierText(tokens[next].Text), alias: ""}
+			next += 1
+
+			if next+1 < len(tokens) && tokens[next].Text == "." && isWord(tokens[next+1]) {
+				reference.table = identifierText(tokens[next+1].Text)
+				next += 2
+			}
+
+			if next < len(tokens) && strings.EqualFold(tokens[next].Text, "AS") {
+				next += 1
+			}
+			if next < len(tokens) && tokens[next].Kind == sqlsyntax.TokenIdentifier {
+				reference.alias = identifierText(tokens[next].Text)
+				next += 1
+			}
+
+			references = append(references, reference)
+			if keyword != "FROM" || next >= len(tokens) || tokens[next].Text != "," {
+				break
+			}
+			next += 1
+		}
+		index = next - 1
+	}
+
+	return references
+}
+
+func resolveTable(name string, references []tableReference, tables []string) string {
+	for _, reference := range references {
+		if strings.EqualFold(reference.alias, name) {
+			name = reference.table
+			break
+		}
+	}
+
+	for _, table := range tables {
+		if strings.EqualFold(table, name) {
+			return table
+		}
+	}
+
+	return name
+}
+
+func qualifierBefore(tokens []sqlsyntax.Token, dot int) string {
+	for _, token := range tokens {
+		if token.End == dot && isWord(token) {
+			return identifierText(token.Text)
+		}
+	}
+	return ""
+}
+
+func previousWord(tokens []sqlsyntax.Token, position int) string {
+	previous := ""
+	for _, token := range tokens {
+		if token.End > position {
+			break
+		}
+		if isWord(token) {
+			previous = strings.ToUpper(token.Text)
+		} else {
+			previous = ""
+		}
+	}
+	return previous
+}
+
+func insideLiteral(before string) bool {
+	tokens := sqlsyntax.Tokenize(before)
+	if len(tokens) == 0 {
+		return false
+	}
+
+	last := tokens[len(tokens)-1]
+	if last.Unterminated && last.Kind != sqlsyntax.TokenIdentifier {
+		return true
+	}
+	return last.Kind == sqlsyntax.TokenComment && strings.HasPrefix(last.Text, "--")
+}
+
+func significantTokens(text string) []sqlsyntax.Token {
+	tokens := []sqlsyntax.Token{}
+	for _, token := range sqlsyntax.Tokenize(text) {
+		if token.Kind == sqlsyntax.TokenWhitespace || token.Kind == sqlsyntax.TokenComment {
+			continue
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+func isWord(token sqlsyntax.Token) bool {
+	return token.Kind == sqlsyntax.TokenIdentifier || token.Kind == sqlsyntax.TokenKeyword
+}
+
+func currentStatement(tokens []sqlsyntax.Token, cursor int) []sqlsyntax.Token {
+	start := 0
+	end := len(tokens)
+	for index, token := range tokens {
+		if token.Text != ";" {
+			continue
+		}
+		if token.End <= cursor {
+			start = index + 1
+			continue
+		}
+		end = index
+		break
+	}
+	return tokens[start:end]
+}
+
+func identifierText(text string) string {
+	if len(text) < 2 {
+		return text
+	}
+
+	switch text[0] {
+	case '"':
+		return strings.ReplaceAll(strings.TrimSuffix(text[1:], "\""), "\"\"", "\"")
+	case '`':
+		return strings.ReplaceAll(strings.TrimSuffix(text[1:], "`"), "``", "`")
+	case '[':
+		return strings.TrimSuffix(text[1:], "]")
+	default:
+		return text
+	}
+}
+
+func isPlainIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for index, r := range name {
+		if r == '_' || unicode.IsLetter(r) {
+			continue
+		}
+		if index > 0 && (unicode.IsDigit(r) || r == '$') {
+			continue
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,162 @@
+package completion
+
+import (
+	"strings"
+	"testing"
+)
+
+func testSchema() Schema {
+	columns := map[string][]string{
+		"customers":   {"id", "name", "email"},
+		"orders":      {"id", "customer_id", "total"},
+		"order items": {"order_id", "sku"},
+	}
+
+	return Schema{
+		Tables: []string{"customers", "orders", "order items"},
+		Columns: func(table string) []string {
+			return columns[table]
+		},
+	}
+}
+
+func itemTexts(items []Item) []string {
+	texts := []string{}
+	for _, item := range items {
+		texts = append(texts, item.Text)
+	}
+	return texts
+}
+
+func completeAtEnd(text string) Result {
+	return Complete(text, len(text), testSchema())
+}
+
+func TestComplete_TablesAfterFrom(t *testing.T) {
+	result := completeAtEnd("SELECT * FROM ord")
+	if result.Prefix != "ord" {
+		t.Fatalf("expected prefix ord, got %q", result.Prefix)
+	}
+
+	texts := itemTexts(result.Items)
+	if len(texts) != 2 || texts[0] != "orders" || texts[1] != "order items" {
+		t.Fatalf("unexpected items: %v", texts)
+	}
+	for _, item := range result.Items {
+		if item.Kind != KindTable {
+			t.Fatalf("expected only tables, got %+v", item)
+		}
+	}
+}
+
+func TestComplete_QualifiedColumns(t *testing.T) {
+	text := "SELECT o. FROM orders AS o"
+	result := Complete(text, strings.Index(text, ".")+1, testSchema())
+	if result.Qualifier != "o" {
+		t.Fatalf("expected qualifier o, got %q", result.Qualifier)
+	}
+	if strings.Join(itemTexts(result.Items), ",") != "id,customer_id,total" {
+		t.Fatalf("unexpected items: %v", itemTexts(result.Items))
+	}
+
+	result = completeAtEnd("SELECT * FROM customers c JOIN orders o ON o.customer_id = c.na")
+	if strings.Join(itemTexts(result.Items), ",") != "name" {
+		t.Fatalf("unexpected items: %v", itemTexts(result.Items))
+	}
+
+	result = completeAtEnd("SELECT \"order items\".s")
+	if strings.Join(itemTexts(result.Items), ",") != "sku" {
+		t.Fatalf("unexpected items: %v", itemTexts(result.Items))
+	}
+}
+
+func TestComplete_ColumnsFromReferencedTables(t *testing.T) {
+	text := "SELECT tot FROM orders"
+	result := Complete(text, len("SELECT tot"), testSchema())
+	if len(result.Items) == 0 || result.Items[0].Text != "total" || result.Items[0].Kind != KindColumn {
+		t.Fatalf("expected total column first, got %+v", result.Items)
+	}
+
+	result = completeAtEnd("SELECT 1; SELECT * FROM customers WHERE em")
+	if len(result.Items) == 0 || result.Items[0].Text != "email" {
+		t.Fatalf("expected email first, got %v", itemTexts(result.Items))
+	}
+
+	result = Complete("SELECT tot FROM customers; SELECT 1 FROM orders", len("SELECT tot"), testSchema())
+	for _, item := range result.Items {
+		if item.Kind == KindColumn {
+			t.Fatalf("columns from another statement leaked: %+v", item)
+		}
+	}
+}
+
+func TestComplete_KeywordsAndFunctions(t *testing.T) {
+	result := completeAtEnd("sel")
+	if len(result.Items) == 0 || result.Items[0].Text != "select" || result.Items[0].Kind != KindKeyword {
+		t.Fatalf("expected lowercase select first, got %+v", result.Items)
+	}
+
+	result = completeAtEnd("SELECT GRP")
+	found := false
+	for _, item := range result.Items {
+		if item.Text == "group_concat" && item.Kind == KindFunction {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected fuzzy match on group_concat, got %v", itemTexts(result.Items))
+	}
+
+	for _, text := range []string{"SELECT 'fro", "SELECT 1 -- fro", "SELECT /* fro"} {
+		result = completeAtEnd(text)
+		if len(result.Items) != 0 {
+			t.Fatalf("expected no items inside %q, got %v", text, itemTexts(result.Items))
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		text    string
+		ok      bool
+	}{
+		{pattern: "", text: "anything", ok: true},
+		{pattern: "cid", text: "customer_id", ok: true},
+		{pattern: "CUS", text: "customers", ok: true},
+		{pattern: "xyz", text: "customers", ok: false},
+		{pattern: "idc", text: "customer_id", ok: false},
+	}
+
+	for _, test := range tests {
+		_, ok := Match(test.pattern, test.text)
+		if ok != test.ok {
+			t.Fatalf("Match(%q, %q) = %v, want %v", test.pattern, test.text, ok, test.ok)
+		}
+	}
+
+	prefixScore, _ := Match("cu", "customers")
+	fuzzyScore, _ := Match("cu", "account_user")
+	if prefixScore <= fuzzyScore {
+		t.Fatalf("expected prefix match to rank higher: %d <= %d", prefixScore, fuzzyScore)
+	}
+}
+
+func TestItemInsert(t *testing.T) {
+	tests := []struct {
+		item Item
+		want string
+	}{
+		{item: Item{Text: "count", Kind: KindFunction}, want: "count("},
+		{item: Item{Text: "orders", Kind: KindTable}, want: "orders"},
+		{item: Item{Text: "order items", Kind: KindTable}, want: "\"order items\""},
+		{item: Item{Text: "group", Kind: KindColumn}, want: "\"group\""},
+		{item: Item{Text: "SELECT", Kind: KindKeyword}, want: "SELECT"},
+	}
+
+	for _, test := range tests {
+		if got := test.item.Insert(); got != test.want {
+			t.Fatalf("Insert(%+v) = %q, want %q", test.item, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package completion
+
+var Functions = []string{
+	"abs", "changes", "char", "coalesce", "concat", "concat_ws", "format", "glob", "hex",
+	"ifnull", "iif", "instr", "last_insert_rowid", "length", "like", "likelihood", "likely",
+	"lower", "ltrim", "max", "min", "nullif", "octet_length", "printf", "quote", "random",
+	"randomblob", "replace", "round", "rtrim", "sign", "soundex", "substr", "substring",
+	"total_changes", "trim", "typeof", "unhex", "unicode", "unlikely", "upper", "zeroblob",
+	"avg", "count", "group_concat", "string_agg", "sum", "total",
+	"date", "time", "datetime", "julianday", "unixepoch", "strftime", "timediff",
+	"acos", "acosh", "asin", "asinh", "atan", "atan2", "atanh", "ceil", "ceiling", "cos",
+	"cosh", "degrees", "exp", "floor", "ln", "log", "log10", "log2", "mod", "pi", "pow",
+	"power", "radians", "sin", "sinh", "sqrt", "tan", "tanh", "trunc",
+	"json", "jsonb", "json_array", "json_array_length", "json_error_position", "json_extract",
+	"json_insert", "json_object", "json_patch", "json_pretty", "json_remove", "json_replace",
+	"json_set", "json_type", "json_valid", "json_quote", "json_group_array",
+	"json_group_object", "json_each", "json_tree",
+	"row_number", "rank", "dense_rank", "percent_rank", "cume_dist", "ntile", "lag", "lead",
+	"first_value", "last_value", "nth_value",
+}
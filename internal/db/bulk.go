@@ -0,0 +1,126 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const importProgressInterval = 1000
+
+type ColumnDefinition struct {
+	Name string
+	Type string
+}
+
+type RowSource func() ([]SqliteValue, error)
+
+func CreateTableStatement(tableName string, columns []ColumnDefinition) string {
+	definitions := []string{}
+	for _, column := range columns {
+		definition := quoteIdentifier(column.Name)
+		if column.Type != "" {
+			definition += " " + column.Type
+		}
+		definitions = append(definitions, definition)
+	}
+
+	return fmt.Sprintf("CREATE TABLE %s (%s)", quoteIdentifier(tableName), strings.Join(definitions, ", "))
+}
+
+func ImportRows(ctx context.Context, db *sql.DB, tableName string, columns []ColumnDefinition, next RowSource, progress func(rows int)) (count int, err error) {
+	if len(columns) == 0 {
+		return 0, fmt.Errorf("no columns to import")
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		closeErr := conn.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil && !errors.Is(rollbackErr, sql.ErrTxDone) {
+			err = fmt.Errorf("%w; rollback error: %v", err, rollbackErr)
+		}
+	}()
+
+	_, err = tx.ExecContext(ctx, CreateTableStatement(tableName, columns))
+	if err != nil {
+		return 0, err
+	}
+
+	names := []string{}
+	for _, column := range columns {
+		names = append(names, quoteIdentifier(column.Name))
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+	insertSql := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quoteIdentifier(tableName), strings.Join(names, ", "), placeholders)
+
+	stmt, err := tx.PrepareContext(ctx, insertSql)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		closeErr := stmt.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	args := make([]any, len(columns))
+	for {
+		values, nextErr := next()
+		if errors.Is(nextErr, io.EOF) {
+			break
+		}
+		if nextErr != nil {
+			return count, nextErr
+		}
+
+		for i := range args {
+			args[i] = nil
+			if i < len(values) {
+				args[i] = values[i]
+			}
+		}
+
+		_, err = stmt.ExecContext(ctx, args...)
+		if err != nil {
+			return count, fmt.Errorf("row %d: %w", count+1, err)
+		}
+
+		count += 1
+		if progress != nil && count%importProgressInterval == 0 {
+			progress(count)
+		}
+	}
+
+	err = commitOrRollback(conn, tx)
+	if err != nil {
+		return count, err
+	}
+
+	if progress != nil {
+		progress(count)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"net/url"
+	"path/filepath"
+	"testing"
+)
+
+func openBusyTestDb(t *testing.T) *sql.DB {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "busy.db")
+	db, err := sql.Open("sqlite", "file:"+url.PathEscape(path)+"?mode=rwc&_pragma=busy_timeout(50)")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	db.SetMaxOpenConns(2)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	_, err = db.Exec("CREATE TABLE items (n INTEGER)")
+	if err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	for n := range 30 {
+		_, err = db.Exec("INSERT INTO items (n) VALUES (?)", n)
+		if err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+
+	return db
+}
+
+func singleRowSource() RowSource {
+	sent := false
+	return func() ([]SqliteValue, error) {
+		if sent {
+			return nil, io.EOF
+		}
+		sent = true
+		return []SqliteValue{int64(1)}, nil
+	}
+}
+
+func TestImportRows_RollsBackWhenCommitIsBusy(t *testing.T) {
+	db := openBusyTestDb(t)
+	columns := []ColumnDefinition{{Name: "n", Type: "INTEGER"}}
+
+	cursor, err := OpenQueryCursor(context.Background(), db, "SELECT n FROM items")
+	if err != nil {
+		t.Fatalf("open cursor: %v", err)
+	}
+	_, err = cursor.Fetch(10)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+
+	_, err = ImportRows(context.Background(), db, "imported", columns, singleRowSource(), nil)
+	if err == nil {
+		t.Fatalf("expected the import to fail while a reader holds the database")
+	}
+
+	err = cursor.Close()
+	if err != nil {
+		t.Fatalf("close cursor: %v", err)
+	}
+
+	for range 2 {
+		count, err := ImportRows(context.Background(), db, "imported", columns, singleRowSource(), nil)
+		if err != nil {
+			t.Fatalf("import after the reader closed: %v", err)
+		}
+		if count != 1 {
+			t.Fatalf("expected 1 row, got %d", count)
+		}
+
+		_, err = db.Exec("DROP TABLE imported")
+		if err != nil {
+			t.Fatalf("drop: %v", err)
+		}
+	}
+}
+
+func TestMakeDsn(t *testing.T) {
+	cases := []struct {
+		path string
+		mode string
+		want string
+	}{
+		{path: "/data/app.db", mode: "ro", want: "file:%2Fdata%2Fapp.db?mode=ro"},
+		{path: "/data/app.db", mode: "rw", want: "file:%2Fdata%2Fapp.db?mode=rw&_pragma=busy_timeout(5000)"},
+		{path: "file:app.db?cache=shared", mode: "rwc", want: "file:app.db?cache=shared&mode=rwc&_pragma=busy_timeout(5000)"},
+		{path: "file:app.db?mode=memory", mode: "rw", want: "file:app.db?mode=memory"},
+	}
+
+	for _, tc := range cases {
+		got := makeDsn(tc.path, tc.mode)
+		if got != tc.want {
+			t.Fatalf("makeDsn(%q, %q) = %q, want %q", tc.path, tc.mode, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type ChangeKind string
+
+const (
+	ChangeUpdate ChangeKind = "update"
+	ChangeInsert ChangeKind = "insert"
+	ChangeDelete ChangeKind = "delete"
+)
+
+type RowKey struct {
+	Columns []string
+	Values  []SqliteValue
+}
+
+type RowChange struct {
+	Kind   ChangeKind
+	Table  string
+	Key    RowKey
+	Values map[string]SqliteValue
+}
+
+func GetRowKeyColumns(db *sql.DB, tableName string) ([]string, error) {
+	columns, err := GetTableColumns(db, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	names := primaryKeyColumns(columns)
+	if len(names) == 0 {
+		return []string{RowIDColumn}, nil
+	}
+
+	return names, nil
+}
+
+func RowKeyFor(keyColumns []string, row SqliteRow) (key RowKey, ok bool) {
+	if len(keyColumns) == 0 {
+		return key, false
+	}
+
+	values := []SqliteValue{}
+	for _, column := range keyColumns {
+		value, ok := row[column]
+		if !ok {
+			return key, false
+		}
+		values = append(values, value)
+	}
+
+	return RowKey{Columns: keyColumns, Values: values}, true
+}
+
+func (key RowKey) Equal(other RowKey) bool {
+	if len(key.Columns) != len(other.Columns) || len(key.Values) != len(other.Values) {
+		return false
+	}
+
+	for i := range key.Columns {
+		if key.Columns[i] != other.Columns[i] {
+			return false
+		}
+		if fmt.Sprint(key.Values[i]) != fmt.Sprint(other.Values[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func ApplyChanges(db *sql.DB, changes []RowChange) (err error) {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		closeErr := conn.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil && !errors.Is(rollbackErr, sql.ErrTxDone) {
+			err = fmt.Errorf("%w; rollback error: %v", err, rollbackErr)
+		}
+	}()
+
+	for _, change := range changes {
+		err = applyChange(tx, change)
+		if err != nil {
+			return err
+		}
+	}
+
+	return commitOrRollback(conn, tx)
+}
+
+func applyChange(tx *sql.Tx, change RowChange) error {
+	switch change.Kind {
+	case ChangeUpdate:
+		return applyUpdate(tx, change)
+	case ChangeInsert:
+		return applyInsert(tx, change)
+	case ChangeDelete:
+		return applyDelete(tx, change)
+	default:
+		return fmt.Errorf("unknown change kind %q", change.Kind)
+	}
+}
+
+func applyUpdate(tx *sql.Tx, change RowChange) error {
+	if len(change.Values) == 0 {
+		return nil
+	}
+
+	columns := sortedValueColumns(change.Values)
+	assignments := []string{}
+	args := []any{}
+	for _, column := range columns {
+		assignments = append(assignments, quoteIdentifier(column)+" = ?")
+		args = append(args, change.Values[column])
+	}
+
+	where, whereArgs, err := buildKeyWhere(change.Key)
+	if err != nil {
+		return err
+	}
+	args = append(args, whereArgs...)
+
+	sqlText := fmt.Sprintf("UPDATE %s SET %s WHERE %s", quoteIdentifier(change.Table), strings.Join(assignments, ", "), where)
+	return execSingleRow(tx, sqlText, args)
+}
+
+func applyInsert(tx *sql.Tx, change RowChange) error {
+	if len(change.Values) == 0 {
+		sqlText := fmt.Sprintf("INSERT INTO %s DEFAULT VALUES", quoteIdentifier(change.Table))
+		_, err := tx.Exec(sqlText)
+		return err
+	}
+
+	columns := sortedValueColumns(change.Values)
+	names := []string{}
+	placeholders := []string{}
+	args := []any{}
+	for _, column := range columns {
+		names = append(names, quoteIdentifier(column))
+		placeholders = append(placeholders, "?")
+		args = append(args, change.Values[column])
+	}
+
+	sqlText := fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES (%s)",
+		quoteIdentifier(change.Table),
+		strings.Join(names, ", "),
+		strings.Join(placeholders, ", "),
+	)
+	_, err := tx.Exec(sqlText, args...)
+	return err
+}
+
+func applyDelete(tx *sql.Tx, change RowChange) error {
+	where, args, err := buildKeyWhere(change.Key)
+	if err != nil {
+		return err
+	}
+
+	sqlText := fmt.Sprintf("DELETE FROM %s WHERE %s", quoteIdentifier(change.Table), where)
+	return execSingleRow(tx, sqlText, args)
+}
+
+func execSingleRow(tx *sql.Tx, sqlText string, args []any) error {
+	result, err := tx.Exec(sqlText, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return fmt.Errorf("expected to change 1 row, changed %d", affected)
+	}
+
+	return nil
+}
+
+func KeyFilter(key RowKey) (string, []any, error) {
+	return buildKeyWhere(key)
+}
+
+func LiteralCondition(key RowKey) string {
+	conditions := []string{}
+	for i, column := range key.Columns {
+		target := keyColumnSql(column)
+		if i >= len(key.Values) || key.Values[i] == nil {
+			conditions = append(conditions, target+" IS NULL")
+			continue
+		}
+
+		conditions = append(conditions, target+" = "+FormatLiteral(key.Values[i]))
+	}
+
+	return strings.Join(conditions, " AND ")
+}
+
+func InsertStatement(tableName string, columns []string, values []SqliteValue) string {
+	names := []string{}
+	literals := []string{}
+	for i, column := range columns {
+		names = append(names, quoteIdentifier(column))
+		literals = append(literals, FormatLiteral(values[i]))
+	}
+
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s);", quoteIdentifier(tableName), strings.Join(names, ", "), strings.Join(literals, ", "))
+}
+
+func FormatLiteral(value SqliteValue) string {
+	switch typed := value.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		return fmt.Sprintf("X'%X'", typed)
+	case string:
+		return "'" + strings.ReplaceAll(typed, "'", "''") + "'"
+	case bool:
+		if typed {
+			return "1"
+		}
+		return "0"
+	case int, int32, int64:
+		return fmt.Sprint(typed)
+	case float32:
+		return formatReal(float64(typed), 32)
+	case float64:
+		return formatReal(typed, 64)
+	default:
+		return "'" + strings.ReplaceAll(fmt.Sprint(typed), "'", "''") + "'"
+	}
+}
+
+// formatReal keeps a decimal point so the literal reads back as REAL, and
+// spells infinities as out-of-range literals SQLite parses as ±Inf.
+func formatReal(value float64, bitSize int) string {
+	switch {
+	case math.IsNaN(value):
+		return "NULL"
+	case math.IsInf(value, 1):
+		return "9e999"
+	case math.IsInf(value, -1):
+		return "-9e999"
+	}
+
+	text := strconv.FormatFloat(value, 'g', -1, bitSize)
+	if !strings.ContainsAny(text, ".en") {
+		text += ".0"
+	}
+	return text
+}
+
+func buildKeyWhere(key RowKey) (string, []any, error) {
+	if len(key.Columns) == 0 || len(key.Columns) != len(key.Values) {
+		return "", nil, fmt.Errorf("row key is incomplete")
+	}
+
+	conditions := []string{}
+	args := []any{}
+	for i, column := range key.Columns {
+		target := keyColumnSql(column)
+
+		value := key.Values[i]
+		if value == nil {
+			conditions = append(conditions, target+" IS NULL")
+			continue
+		}
+
+		conditions = append(conditions, target+" = ?")
+		args = append(args, value)
+	}
+
+	return strings.Join(conditions, " AND "), args, nil
+}
+
+func sortedValueColumns(values map[string]SqliteValue) []string {
+	return slices.Sorted(maps.Keys(values))
+}
@@ -0,0 +1,359 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"testing"
+)
+
+func TestGetRowKeyColumns(t *testing.T) {
+	db := createTestDb(t)
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatalf("close db: %v", err)
+		}
+	}()
+
+	_, err := db.Exec("CREATE TABLE memberships (team_id INTEGER, user_id INTEGER, role TEXT, PRIMARY KEY (user_id, team_id))")
+	if err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+
+	_, err = db.Exec("CREATE TABLE notes (body TEXT)")
+	if err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+
+	keyColumns, err := GetRowKeyColumns(db, "memberships")
+	if err != nil {
+		t.Fatalf("get key columns: %v", err)
+	}
+
+	if len(keyColumns) != 2 || keyColumns[0] != "user_id" || keyColumns[1] != "team_id" {
+		t.Fatalf("unexpected key columns: %v", keyColumns)
+	}
+
+	keyColumns, err = GetRowKeyColumns(db, "notes")
+	if err != nil {
+		t.Fatalf("get key columns: %v", err)
+	}
+
+	if len(keyColumns) != 1 || keyColumns[0] != RowIDColumn {
+		t.Fatalf("expected rowid key, got %v", keyColumns)
+	}
+}
+
+func TestQueryTablePage_IncludeRowID(t *testing.T) {
+	db := createTestDb(t)
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatalf("close db: %v", err)
+		}
+	}()
+
+	_, err := db.Exec("CREATE TABLE notes (body TEXT)")
+	if err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+
+	_, err = db.Exec("INSERT INTO notes (body) VALUES ('a'), ('b')")
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	page, err := QueryTablePage(db, TablePageQuery{
+		Table:        "notes",
+		Limit:        10,
+		Offset:       0,
+		IncludeRowID: true,
+		KeyColumns:   nil,
+		Anchors:      nil,
+		Where:        "",
+		WhereArgs:    nil,
+		OrderBy:      nil,
+		CountRows:    true,
+		TotalRows:    0,
+	})
+	if err != nil {
+		t.Fatalf("get page: %v", err)
+	}
+
+	if len(page.Rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(page.Rows))
+	}
+
+	if page.Rows[1][RowIDColumn] != int64(2) || page.Rows[1]["body"] != "b" {
+		t.Fatalf("unexpected row: %v", page.Rows[1])
+	}
+}
+
+func TestApplyChanges(t *testing.T) {
+	db := createTestDb(t)
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatalf("close db: %v", err)
+		}
+	}()
+
+	_, err := db.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)")
+	if err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+
+	_, err = db.Exec("INSERT INTO users (id, name) VALUES (1, 'A'), (2, 'B')")
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	changes := []RowChange{
+		{
+			Kind:   ChangeUpdate,
+			Table:  "users",
+			Key:    RowKey{Columns: []string{"id"}, Values: []SqliteValue{int64(1)}},
+			Values: map[string]SqliteValue{"name": "Ava"},
+		},
+		{
+			Kind:   ChangeDelete,
+			Table:  "users",
+			Key:    RowKey{Columns: []string{"id"}, Values: []SqliteValue{int64(2)}},
+			Values: nil,
+		},
+		{
+			Kind:   ChangeInsert,
+			Table:  "users",
+			Key:    RowKey{Columns: nil, Values: nil},
+			Values: map[string]SqliteValue{"id": int64(3), "name": "Mateo"},
+		},
+	}
+
+	err = ApplyChanges(db, changes)
+	if err != nil {
+		t.Fatalf("apply changes: %v", err)
+	}
+
+	result, err := QueryRows(db, "SELECT id, name FROM users ORDER BY id", 0)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+
+	if len(result.Rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(result.Rows))
+	}
+
+	if result.Rows[0]["name"] != "Ava" || result.Rows[1]["name"] != "Mateo" {
+		t.Fatalf("unexpected rows: %v", result.Rows)
+	}
+}
+
+func TestApplyChanges_RollsBackOnError(t *testing.T) {
+	db := createTestDb(t)
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatalf("close db: %v", err)
+		}
+	}()
+
+	_, err := db.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)")
+	if err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+
+	_, err = db.Exec("INSERT INTO users (id, name) VALUES (1, 'A')")
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	changes := []RowChange{
+		{
+			Kind:   ChangeUpdate,
+			Table:  "users",
+			Key:    RowKey{Columns: []string{"id"}, Values: []SqliteValue{int64(1)}},
+			Values: map[string]SqliteValue{"name": "Ava"},
+		},
+		{
+			Kind:   ChangeDelete,
+			Table:  "users",
+			Key:    RowKey{Columns: []string{"id"}, Values: []SqliteValue{int64(99)}},
+			Values: nil,
+		},
+	}
+
+	err = ApplyChanges(db, changes)
+	if err == nil {
+		t.Fatalf("expected error for missing row")
+	}
+
+	result, err := QueryRows(db, "SELECT name FROM users WHERE id = 1", 0)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+
+	if result.Rows[0]["name"] != "A" {
+		t.Fatalf("expected rollback, got %v", result.Rows[0]["name"])
+	}
+}
+
+func TestApplyChanges_RollsBackWhenCommitIsBusy(t *testing.T) {
+	db := openBusyTestDb(t)
+
+	cursor, err := OpenQueryCursor(context.Background(), db, "SELECT n FROM items")
+	if err != nil {
+		t.Fatalf("open cursor: %v", err)
+	}
+	_, err = cursor.Fetch(10)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+
+	deleteRow := func(n int64) []RowChange {
+		return []RowChange{{
+			Kind:   ChangeDelete,
+			Table:  "items",
+			Key:    RowKey{Columns: []string{RowIDColumn}, Values: []SqliteValue{n}},
+			Values: nil,
+		}}
+	}
+
+	err = ApplyChanges(db, deleteRow(1))
+	if err == nil {
+		t.Fatalf("expected the commit to fail while a reader holds the database")
+	}
+
+	err = cursor.Close()
+	if err != nil {
+		t.Fatalf("close cursor: %v", err)
+	}
+
+	for _, n := range []int64{1, 2} {
+		err = ApplyChanges(db, deleteRow(n))
+		if err != nil {
+			t.Fatalf("apply changes after the reader closed: %v", err)
+		}
+	}
+
+	count, err := CountTableRows(db, "items")
+	if err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if count != 28 {
+		t.Fatalf("expected 28 rows, got %d", count)
+	}
+}
+
+func TestLiteralCondition(t *testing.T) {
+	db := createTestDb(t)
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatalf("close db: %v", err)
+		}
+	}()
+
+	_, err := db.Exec("CREATE TABLE samples (label TEXT, amount REAL, data BLOB, note TEXT)")
+	if err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+
+	_, err = db.Exec("INSERT INTO samples VALUES ('it''s', 0.1, x'00ff', NULL), ('other', 2.5, x'01', 'x')")
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	result, err := QueryRows(db, "SELECT * FROM samples", 0)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+
+	for _, row := range result.Rows {
+		key, ok := RowKeyFor([]string{"label", "amount", "data", "note"}, row)
+		if !ok {
+			t.Fatalf("expected key for %v", row)
+		}
+
+		count, err := CountFilteredRows(db, "samples", LiteralCondition(key), nil)
+		if err != nil {
+			t.Fatalf("count %q: %v", LiteralCondition(key), err)
+		}
+		if count != 1 {
+			t.Fatalf("expected %q to match one row, got %d", LiteralCondition(key), count)
+		}
+	}
+}
+
+func TestFormatLiteral_Reals(t *testing.T) {
+	db := createTestDb(t)
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatalf("close db: %v", err)
+		}
+	}()
+
+	tests := []struct {
+		value    SqliteValue
+		want     string
+		readBack string
+	}{
+		{value: 1.0, want: "1.0", readBack: "real 1.0"},
+		{value: -2.5, want: "-2.5", readBack: "real -2.5"},
+		{value: 1e21, want: "1e+21", readBack: "real 1.0e+21"},
+		{value: float32(0.1), want: "0.1", readBack: "real 0.1"},
+		{value: math.Inf(1), want: "9e999", readBack: "real Inf"},
+		{value: math.Inf(-1), want: "-9e999", readBack: "real -Inf"},
+		{value: math.NaN(), want: "NULL", readBack: "null "},
+		{value: int64(1), want: "1", readBack: "integer 1"},
+	}
+
+	for _, test := range tests {
+		literal := FormatLiteral(test.value)
+		if literal != test.want {
+			t.Fatalf("FormatLiteral(%v) = %q, want %q", test.value, literal, test.want)
+		}
+
+		var readBack string
+		err := db.QueryRow("SELECT typeof(v) || ' ' || coalesce(CAST(v AS TEXT), '') FROM (SELECT " + literal + " AS v)").Scan(&readBack)
+		if err != nil {
+			t.Fatalf("read back %s: %v", literal, err)
+		}
+		if readBack != test.readBack {
+			t.Fatalf("%s reads back as %q, want %q", literal, readBack, test.readBack)
+		}
+	}
+}
+
+func TestInsertStatement(t *testing.T) {
+	db := createTestDb(t)
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatalf("close db: %v", err)
+		}
+	}()
+
+	_, err := db.Exec(`CREATE TABLE "odd ""name""" (label TEXT, amount REAL, data BLOB, note TEXT)`)
+	if err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+
+	columns := []string{"label", "amount", "data", "note"}
+	values := []SqliteValue{"it's", 0.1, []byte{0x00, 0xff}, nil}
+	_, err = db.Exec(InsertStatement(`odd "name"`, columns, values))
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	result, err := QueryRows(db, `SELECT * FROM "odd ""name"""`, 0)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+
+	row := result.Rows[0]
+	if row["label"] != "it's" || row["amount"] != 0.1 || !bytes.Equal(row["data"].([]byte), []byte{0x00, 0xff}) || row["note"] != nil {
+		t.Fatalf("expected values to round-trip, got %v", row)
+	}
+}
@@ -0,0 +1,135 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+type QueryCursor struct {
+	rows      *sql.Rows
+	columns   []string
+	values    []any
+	valuePtrs []any
+	position  int
+	done      bool
+	release   func() error
+}
+
+func OpenQueryCursor(ctx context.Context, db *sql.DB, sqlText string, args ...any) (*QueryCursor, error) {
+	rows, err := db.QueryContext(ctx, sqlText, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := newQueryCursor(rows)
+	if err != nil {
+		_ = rows.Close()
+		return nil, err
+	}
+
+	return cursor, nil
+}
+
+func newQueryCursor(rows *sql.Rows) (*QueryCursor, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]any, len(columns))
+	valuePtrs := make([]any, len(columns))
+	for i := range valuePtrs {
+		valuePtrs[i] = &values[i]
+	}
+
+	return &QueryCursor{
+		rows:      rows,
+		columns:   columns,
+		values:    values,
+		valuePtrs: valuePtrs,
+		position:  0,
+		done:      false,
+		release:   nil,
+	}, nil
+}
+
+func (cursor *QueryCursor) Columns() []string {
+	return cursor.columns
+}
+
+func (cursor *QueryCursor) Position() int {
+	return cursor.position
+}
+
+func (cursor *QueryCursor) Done() bool {
+	return cursor.done
+}
+
+func (cursor *QueryCursor) Fetch(limit int) ([]SqliteRow, error) {
+	resultRows := []SqliteRow{}
+
+	for limit <= 0 || len(resultRows) < limit {
+		if !cursor.advance() {
+			break
+		}
+
+		err := cursor.rows.Scan(cursor.valuePtrs...)
+		if err != nil {
+			return nil, err
+		}
+
+		row := make(SqliteRow, len(cursor.columns))
+		for i, col := range cursor.columns {
+			row[col] = normalizeValue(cursor.values[i])
+		}
+
+		resultRows = append(resultRows, row)
+	}
+
+	err := cursor.rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return resultRows, nil
+}
+
+func (cursor *QueryCursor) Skip(count int) error {
+	for skipped := 0; skipped < count; skipped += 1 {
+		if !cursor.advance() {
+			break
+		}
+	}
+
+	return cursor.rows.Err()
+}
+
+func (cursor *QueryCursor) Close() error {
+	cursor.done = true
+	err := cursor.rows.Close()
+	if cursor.release == nil {
+		return err
+	}
+
+	release := cursor.release
+	cursor.release = nil
+	releaseErr := release()
+	if err != nil {
+		return err
+	}
+	return releaseErr
+}
+
+func (cursor *QueryCursor) advance() bool {
+	if cursor.done {
+		return false
+	}
+
+	if !cursor.rows.Next() {
+		cursor.done = true
+		return false
+	}
+
+	cursor.position += 1
+	return true
+}
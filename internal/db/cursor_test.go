@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"testing"
+)
+
+func TestQueryCursor_FetchesInChunks(t *testing.T) {
+	db := createTestDb(t)
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatalf("close db: %v", err)
+		}
+	}()
+
+	sqlText := "WITH RECURSIVE counter(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM counter WHERE n < 25) SELECT n FROM counter"
+	cursor, err := OpenQueryCursor(context.Background(), db, sqlText)
+	if err != nil {
+		t.Fatalf("open cursor: %v", err)
+	}
+	defer func() {
+		err := cursor.Close()
+		if err != nil {
+			t.Fatalf("close cursor: %v", err)
+		}
+	}()
+
+	rows, err := cursor.Fetch(10)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+
+	if len(rows) != 10 || cursor.Done() {
+		t.Fatalf("expected 10 rows and open cursor, got %d done=%v", len(rows), cursor.Done())
+	}
+
+	err = cursor.Skip(5)
+	if err != nil {
+		t.Fatalf("skip: %v", err)
+	}
+
+	rows, err = cursor.Fetch(20)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+
+	if len(rows) != 10 || !cursor.Done() {
+		t.Fatalf("expected 10 remaining rows and done cursor, got %d done=%v", len(rows), cursor.Done())
+	}
+
+	if rows[0]["n"] != int64(16) {
+		t.Fatalf("expected first row after skip to be 16, got %v", rows[0]["n"])
+	}
+
+	if cursor.Position() != 25 {
+		t.Fatalf("expected position 25, got %d", cursor.Position())
+	}
+}
+
+func TestQueryRows_TruncatesAtLimit(t *testing.T) {
+	db := createTestDb(t)
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatalf("close db: %v", err)
+		}
+	}()
+
+	sqlText := "WITH RECURSIVE counter(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM counter WHERE n < 5) SELECT n FROM counter"
+	result, err := QueryRows(db, sqlText, 5)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+
+	if len(result.Rows) != 5 || result.Truncated {
+		t.Fatalf("expected 5 rows without truncation, got %d truncated=%v", len(result.Rows), result.Truncated)
+	}
+
+	result, err = QueryRows(db, sqlText, 3)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+
+	if len(result.Rows) != 3 || !result.Truncated {
+		t.Fatalf("expected 3 truncated rows, got %d truncated=%v", len(result.Rows), result.Truncated)
+	}
+}
@@ -1,9 +1,12 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -11,8 +14,13 @@ type SqliteValue any
 
 type SqliteRow map[string]SqliteValue
 
+const RowIDColumn = "_squlito_rowid_"
+
+const writeBusyTimeoutMillis = 5000
+
 type SqliteTable struct {
 	Name string
+	Type string
 }
 
 type SqliteColumn struct {
@@ -24,10 +32,30 @@ type SqliteColumn struct {
 	PrimaryKey   int
 }
 
+type SortKey struct {
+	Column     string
+	Descending bool
+}
+
+type TablePageQuery struct {
+	Table        string
+	Limit        int
+	Offset       int
+	IncludeRowID bool
+	KeyColumns   []string
+	Anchors      []PageAnchor
+	Where        string
+	WhereArgs    []any
+	OrderBy      []SortKey
+	CountRows    bool
+	TotalRows    int
+}
+
 type TablePage struct {
 	TotalRows int
 	Offset    int
 	Rows      []SqliteRow
+	Anchors   []PageAnchor
 }
 
 type QueryRowsResult struct {
@@ -37,7 +65,22 @@ type QueryRowsResult struct {
 }
 
 func OpenDatabase(dbPath string) (*sql.DB, error) {
-	dsn := makeReadonlyDsn(dbPath)
+	return openWithDsn(makeReadonlyDsn(dbPath))
+}
+
+func OpenWritableDatabase(dbPath string) (*sql.DB, error) {
+	return openWithDsn(makeWritableDsn(dbPath))
+}
+
+func OpenOrCreateDatabase(dbPath string) (*sql.DB, error) {
+	return openWithDsn(makeDsn(dbPath, "rwc"))
+}
+
+func OpenMemoryDatabase(name string) (*sql.DB, error) {
+	return openWithDsn("file:" + url.PathEscape(name) + "?mode=memory&cache=shared")
+}
+
+func openWithDsn(dsn string) (*sql.DB, error) {
 	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, err
@@ -76,7 +119,7 @@ func ListUserTables(db *sql.DB) (tables []SqliteTable, err error) {
 			return nil, err
 		}
 
-		tables = append(tables, SqliteTable{Name: name})
+		tables = append(tables, SqliteTable{Name: name, Type: ObjectTable})
 	}
 
 	err = rows.Err()
@@ -134,35 +177,162 @@ func GetTableColumns(db *sql.DB, tableName string) (columns []SqliteColumn, err
 }
 
 func GetTablePage(db *sql.DB, tableName string, limit int, offset int) (TablePage, error) {
-	safeLimit := clampInt(limit, 1, 500)
-	safeOffset := offset
+	return QueryTablePage(db, TablePageQuery{
+		Table:        tableName,
+		Limit:        limit,
+		Offset:       offset,
+		IncludeRowID: false,
+		KeyColumns:   nil,
+		Anchors:      nil,
+		Where:        "",
+		WhereArgs:    nil,
+		OrderBy:      nil,
+		CountRows:    true,
+		TotalRows:    0,
+	})
+}
+
+func QueryTablePage(db *sql.DB, query TablePageQuery) (TablePage, error) {
+	tableName := query.Table
+	safeLimit := clampInt(query.Limit, 1, 500)
+	safeOffset := query.Offset
 	safeOffset = max(0, safeOffset)
 
-	countSql := fmt.Sprintf("SELECT COUNT(*) AS count FROM %s", quoteIdentifier(tableName))
-	countRow := db.QueryRow(countSql)
-	totalRows := 0
-	err := countRow.Scan(&totalRows)
-	if err != nil {
-		return TablePage{}, err
+	totalRows := query.TotalRows
+	if query.CountRows {
+		count, err := CountFilteredRows(db, tableName, query.Where, query.WhereArgs)
+		if err != nil {
+			return TablePage{}, err
+		}
+		totalRows = count
 	}
 
-	pageSql := fmt.Sprintf("SELECT * FROM %s LIMIT ? OFFSET ?", quoteIdentifier(tableName))
-	result, err := QueryRows(db, pageSql, 0, safeLimit, safeOffset)
+	selectList := "*"
+	if query.IncludeRowID || slices.Contains(query.KeyColumns, RowIDColumn) {
+		selectList = "rowid AS " + quoteIdentifier(RowIDColumn) + ", *"
+	}
+
+	plan := pagePlan{where: "", args: nil, descending: false, fromEnd: false, limit: safeLimit, offset: safeOffset}
+	if len(query.KeyColumns) > 0 && len(query.OrderBy) == 0 {
+		plan = planKeysetPage(query.KeyColumns, query.Anchors, safeOffset, safeLimit, totalRows)
+	} else if len(query.KeyColumns) > 0 {
+		// Sorted pages keep no anchors, but the key columns break ties, so the
+		// reversed order still reaches the back half of the table from its end.
+		plan = planFromEnd(plan, safeOffset, safeOffset, totalRows)
+	}
+
+	conditions := []string{}
+	args := []any{}
+	if query.Where != "" {
+		conditions = append(conditions, "("+query.Where+")")
+		args = append(args, query.WhereArgs...)
+	}
+	if plan.where != "" {
+		conditions = append(conditions, plan.where)
+		args = append(args, plan.args...)
+	}
+
+	pageSql := fmt.Sprintf("SELECT %s FROM %s", selectList, quoteIdentifier(tableName))
+	if len(conditions) > 0 {
+		pageSql += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	orderTerms := sortOrderTerms(reverseSortKeys(query.OrderBy, plan.descending))
+	if len(query.KeyColumns) > 0 {
+		orderTerms = append(orderTerms, keyOrderBy(query.KeyColumns, plan.descending))
+	}
+	if len(orderTerms) > 0 {
+		pageSql += " ORDER BY " + strings.Join(orderTerms, ", ")
+	}
+	if plan.fromEnd {
+		// Count in the same statement: rows written since totalRows was counted
+		// would otherwise shift every page read back from the end.
+		countSql := "(SELECT COUNT(*) FROM " + quoteIdentifier(tableName)
+		if query.Where != "" {
+			countSql += " WHERE (" + query.Where + ")"
+		}
+		countSql += ")"
+		end := plan.offset + plan.limit
+		pageSql += " LIMIT max(min(" + countSql + ", ?) - ?, 0) OFFSET max(" + countSql + " - ?, 0)"
+		args = append(args, query.WhereArgs...)
+		args = append(args, end, plan.offset)
+		args = append(args, query.WhereArgs...)
+		args = append(args, end)
+	} else {
+		pageSql += " LIMIT ? OFFSET ?"
+		args = append(args, plan.limit, plan.offset)
+	}
+
+	result, err := QueryRows(db, pageSql, 0, args...)
 	if err != nil {
 		return TablePage{}, err
 	}
 
+	if plan.descending {
+		slices.Reverse(result.Rows)
+	}
+
 	page := TablePage{
 		TotalRows: totalRows,
 		Offset:    safeOffset,
 		Rows:      result.Rows,
+		Anchors:   nil,
+	}
+	if len(query.OrderBy) == 0 {
+		page.Anchors = pageAnchorsFor(query.KeyColumns, safeOffset, result.Rows)
 	}
 
 	return page, nil
 }
 
-func QueryRows(db *sql.DB, sqlText string, limit int, args ...any) (result QueryRowsResult, err error) {
-	rows, err := db.Query(sqlText, args...)
+func TableSelectSQL(tableName string, where string, whereArgs []any, orderBy []SortKey, keyColumns []string) (string, []any) {
+	sqlText := "SELECT * FROM " + quoteIdentifier(tableName)
+	args := []any{}
+	if where != "" {
+		sqlText += " WHERE (" + where + ")"
+		args = append(args, whereArgs...)
+	}
+
+	orderTerms := sortOrderTerms(orderBy)
+	if len(keyColumns) > 0 {
+		orderTerms = append(orderTerms, keyOrderBy(keyColumns, false))
+	}
+	if len(orderTerms) > 0 {
+		sqlText += " ORDER BY " + strings.Join(orderTerms, ", ")
+	}
+
+	return sqlText, args
+}
+
+func reverseSortKeys(sortKeys []SortKey, reverse bool) []SortKey {
+	if !reverse {
+		return sortKeys
+	}
+
+	reversed := []SortKey{}
+	for _, key := range sortKeys {
+		reversed = append(reversed, SortKey{Column: key.Column, Descending: !key.Descending})
+	}
+	return reversed
+}
+
+func sortOrderTerms(sortKeys []SortKey) []string {
+	terms := []string{}
+	for _, key := range sortKeys {
+		term := quoteIdentifier(key.Column)
+		if key.Descending {
+			term += " DESC"
+		}
+		terms = append(terms, term)
+	}
+	return terms
+}
+
+func QueryRows(db *sql.DB, sqlText string, limit int, args ...any) (QueryRowsResult, error) {
+	return QueryRowsContext(context.Background(), db, sqlText, limit, args...)
+}
+
+func QueryRowsContext(ctx context.Context, db *sql.DB, sqlText string, limit int, args ...any) (result QueryRowsResult, err error) {
+	rows, err := db.QueryContext(ctx, sqlText, args...)
 	if err != nil {
 		return QueryRowsResult{}, err
 	}
@@ -182,46 +352,27 @@ func QueryRows(db *sql.DB, sqlText string, limit int, args ...any) (result Query
 }
 
 func scanRows(rows *sql.Rows, limit int) (QueryRowsResult, error) {
-	columns, err := rows.Columns()
+	cursor, err := newQueryCursor(rows)
 	if err != nil {
 		return QueryRowsResult{}, err
 	}
 
-	values := make([]any, len(columns))
-	valuePtrs := make([]any, len(columns))
-	for i := range valuePtrs {
-		valuePtrs[i] = &values[i]
+	resultRows, err := cursor.Fetch(limit)
+	if err != nil {
+		return QueryRowsResult{}, err
 	}
 
-	resultRows := []SqliteRow{}
 	truncated := false
-
-	for rows.Next() {
-		if limit > 0 && len(resultRows) >= limit {
-			truncated = true
-			break
-		}
-
-		err = rows.Scan(valuePtrs...)
+	if !cursor.Done() {
+		truncated = rows.Next()
+		err = rows.Err()
 		if err != nil {
 			return QueryRowsResult{}, err
 		}
-
-		row := make(SqliteRow, len(columns))
-		for i, col := range columns {
-			row[col] = normalizeValue(values[i])
-		}
-
-		resultRows = append(resultRows, row)
-	}
-
-	err = rows.Err()
-	if err != nil {
-		return QueryRowsResult{}, err
 	}
 
 	result := QueryRowsResult{
-		Columns:   columns,
+		Columns:   cursor.Columns(),
 		Rows:      resultRows,
 		Truncated: truncated,
 	}
@@ -253,6 +404,21 @@ func normalizeValue(value any) SqliteValue {
 }
 
 func makeReadonlyDsn(dbPath string) string {
+	return makeDsn(dbPath, "ro")
+}
+
+func makeWritableDsn(dbPath string) string {
+	return makeDsn(dbPath, "rw")
+}
+
+// makeDsn gives writable connections a busy timeout so a commit waits for a
+// short-lived reader instead of failing with SQLITE_BUSY straight away.
+func makeDsn(dbPath string, mode string) string {
+	options := "mode=" + mode
+	if mode != "ro" {
+		options += "&_pragma=busy_timeout(" + strconv.Itoa(writeBusyTimeoutMillis) + ")"
+	}
+
 	if strings.HasPrefix(dbPath, "file:") {
 		if strings.Contains(dbPath, "mode=") {
 			return dbPath
@@ -263,11 +429,28 @@ func makeReadonlyDsn(dbPath string) string {
 			separator = "&"
 		}
 
-		return dbPath + separator + "mode=ro"
+		return dbPath + separator + options
 	}
 
 	escaped := url.PathEscape(dbPath)
-	return "file:" + escaped + "?mode=ro"
+	return "file:" + escaped + "?" + options
+}
+
+// commitOrRollback commits tx, which must run on conn. When the commit fails,
+// database/sql considers the transaction finished but SQLite may still hold it
+// open, so conn is rolled back explicitly before it goes back to the pool.
+func commitOrRollback(conn *sql.Conn, tx *sql.Tx) error {
+	err := tx.Commit()
+	if err == nil {
+		return nil
+	}
+
+	_, rollbackErr := conn.ExecContext(context.Background(), "ROLLBACK")
+	if rollbackErr != nil && !strings.Contains(rollbackErr.Error(), "no transaction is active") {
+		return fmt.Errorf("%w; rollback error: %v", err, rollbackErr)
+	}
+
+	return err
 }
 
 func quoteIdentifier(identifier string) string {
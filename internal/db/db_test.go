@@ -1,9 +1,11 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"testing"
+	"time"
 
 	_ "modernc.org/sqlite"
 )
@@ -176,3 +178,22 @@ func TestGetTablePage_OffsetBeyondTotal(t *testing.T) {
 		t.Fatalf("expected 0 rows, got %d", len(page.Rows))
 	}
 }
+
+func TestQueryRowsContext_Cancelled(t *testing.T) {
+	db := createTestDb(t)
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatalf("close db: %v", err)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	slowSql := "WITH RECURSIVE counter(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM counter) SELECT COUNT(*) FROM counter"
+	_, err := QueryRowsContext(ctx, db, slowSql, 0)
+	if err == nil {
+		t.Fatalf("expected cancelled query to fail")
+	}
+}
@@ -0,0 +1,250 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type PageAnchor struct {
+	Offset int
+	Key    []SqliteValue
+}
+
+// pagePlan describes how to read one page. A fromEnd plan keeps limit and
+// offset relative to the first row; the page statement counts the rows itself
+// and reads them backwards from the last one.
+type pagePlan struct {
+	where      string
+	args       []any
+	descending bool
+	fromEnd    bool
+	limit      int
+	offset     int
+}
+
+func GetPageKeyColumns(db *sql.DB, tableName string) ([]string, error) {
+	hasRowID, err := tableHasRowID(db, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	if hasRowID {
+		return []string{RowIDColumn}, nil
+	}
+
+	columns, err := GetTableColumns(db, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	return primaryKeyColumns(columns), nil
+}
+
+func CountTableRows(db *sql.DB, tableName string) (int, error) {
+	return CountFilteredRows(db, tableName, "", nil)
+}
+
+func CountFilteredRows(db *sql.DB, tableName string, where string, args []any) (int, error) {
+	countSql := fmt.Sprintf("SELECT COUNT(*) AS count FROM %s", quoteIdentifier(tableName))
+	if where != "" {
+		countSql += " WHERE " + where
+	}
+
+	totalRows := 0
+	err := db.QueryRow(countSql, args...).Scan(&totalRows)
+	if err != nil {
+		return 0, err
+	}
+
+	return totalRows, nil
+}
+
+func MergeAnchors(existing []PageAnchor, added []PageAnchor, limit int) []PageAnchor {
+	merged := slices.Clone(added)
+	for _, anchor := range existing {
+		if !slices.ContainsFunc(merged, func(other PageAnchor) bool { return other.Offset == anchor.Offset }) {
+			merged = append(merged, anchor)
+		}
+	}
+
+	slices.SortFunc(merged, func(a PageAnchor, b PageAnchor) int {
+		return a.Offset - b.Offset
+	})
+
+	for limit > 0 && len(merged) > limit {
+		thinned := []PageAnchor{}
+		for i, anchor := range merged {
+			if i%2 == 0 || i == len(merged)-1 {
+				thinned = append(thinned, anchor)
+			}
+		}
+		merged = thinned
+	}
+
+	return merged
+}
+
+func tableHasRowID(db *sql.DB, tableName string) (bool, error) {
+	sqlText := fmt.Sprintf("SELECT rowid FROM %s LIMIT 0", quoteIdentifier(tableName))
+	rows, err := db.Query(sqlText)
+	if err != nil {
+		if strings.Contains(err.Error(), "no such column") {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, rows.Close()
+}
+
+func primaryKeyColumns(columns []SqliteColumn) []string {
+	primaryKey := []SqliteColumn{}
+	for _, column := range columns {
+		if column.PrimaryKey > 0 {
+			primaryKey = append(primaryKey, column)
+		}
+	}
+
+	slices.SortFunc(primaryKey, func(a SqliteColumn, b SqliteColumn) int {
+		return a.PrimaryKey - b.PrimaryKey
+	})
+
+	names := []string{}
+	for _, column := range primaryKey {
+		names = append(names, column.Name)
+	}
+
+	return names
+}
+
+// planKeysetPage seeks from the nearest anchor, or from either end of the table
+// when that skips fewer rows. Without an anchor nearby, a target in the middle
+// of the table still skips up to half of it; scrolling there leaves anchors
+// behind, so only the first jump pays that cost.
+func planKeysetPage(keyColumns []string, anchors []PageAnchor, target int, limit int, totalRows int) pagePlan {
+	best := pagePlan{where: "", args: nil, descending: false, fromEnd: false, limit: limit, offset: target}
+	cost := target
+
+	for _, anchor := range anchors {
+		if len(anchor.Key) != len(keyColumns) {
+			continue
+		}
+
+		if anchor.Offset <= target && target-anchor.Offset < cost {
+			cost = target - anchor.Offset
+			best = pagePlan{
+				where:      keyExpression(keyColumns) + " >= " + keyPlaceholders(len(keyColumns)),
+				args:       keyArgs(anchor.Key),
+				descending: false,
+				fromEnd:    false,
+				limit:      limit,
+				offset:     cost,
+			}
+			continue
+		}
+
+		if target+limit <= anchor.Offset && anchor.Offset-target-limit < cost {
+			cost = anchor.Offset - target - limit
+			best = pagePlan{
+				where:      keyExpression(keyColumns) + " < " + keyPlaceholders(len(keyColumns)),
+				args:       keyArgs(anchor.Key),
+				descending: true,
+				fromEnd:    false,
+				limit:      limit,
+				offset:     cost,
+			}
+		}
+	}
+
+	return planFromEnd(best, cost, target, totalRows)
+}
+
+// planFromEnd reads the page in reverse order from the last row when that skips
+// fewer rows than best, which costs cost rows. totalRows may be a cached count,
+// so it only picks the plan: the page statement recounts the rows itself.
+func planFromEnd(best pagePlan, cost int, target int, totalRows int) pagePlan {
+	if totalRows < 0 || target >= totalRows {
+		return best
+	}
+
+	end := min(totalRows, target+best.limit)
+	if totalRows-end >= cost {
+		return best
+	}
+
+	return pagePlan{where: "", args: nil, descending: true, fromEnd: true, limit: best.limit, offset: target}
+}
+
+func pageAnchorsFor(keyColumns []string, offset int, rows []SqliteRow) []PageAnchor {
+	if len(keyColumns) == 0 || len(rows) == 0 {
+		return nil
+	}
+
+	anchors := []PageAnchor{}
+	for _, index := range []int{0, len(rows) - 1} {
+		key, ok := RowKeyFor(keyColumns, rows[index])
+		if !ok {
+			return nil
+		}
+		anchors = append(anchors, PageAnchor{Offset: offset + index, Key: key.Values})
+	}
+
+	if len(rows) == 1 {
+		return anchors[:1]
+	}
+
+	return anchors
+}
+
+func keyExpression(keyColumns []string) string {
+	names := []string{}
+	for _, column := range keyColumns {
+		names = append(names, keyColumnSql(column))
+	}
+
+	if len(names) == 1 {
+		return names[0]
+	}
+
+	return "(" + strings.Join(names, ", ") + ")"
+}
+
+func keyOrderBy(keyColumns []string, descending bool) string {
+	direction := ""
+	if descending {
+		direction = " DESC"
+	}
+
+	terms := []string{}
+	for _, column := range keyColumns {
+		terms = append(terms, keyColumnSql(column)+direction)
+	}
+
+	return strings.Join(terms, ", ")
+}
+
+func keyPlaceholders(count int) string {
+	if count == 1 {
+		return "?"
+	}
+
+	return "(" + strings.TrimSuffix(strings.Repeat("?, ", count), ", ") + ")"
+}
+
+func keyArgs(key []SqliteValue) []any {
+	args := []any{}
+	for _, value := range key {
+		args = append(args, value)
+	}
+	return args
+}
+
+func keyColumnSql(column string) string {
+	if column == RowIDColumn {
+		return "rowid"
+	}
+
+	return quoteIdentifier(column)
+}
@@ -0,0 +1,409 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func seedKeysetTable(t *testing.T, db *sql.DB, createSql string, count int) {
+	t.Helper()
+
+	_, err := db.Exec(createSql)
+	if err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+
+	for i := 1; i <= count; i += 1 {
+		_, err = db.Exec("INSERT INTO items (a, b, label) VALUES (?, ?, ?)", i%7, i, fmt.Sprintf("item %d", i))
+		if err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+}
+
+func TestGetPageKeyColumns(t *testing.T) {
+	db := createTestDb(t)
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatalf("close db: %v", err)
+		}
+	}()
+
+	_, err := db.Exec("CREATE TABLE plain (label TEXT)")
+	if err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+
+	_, err = db.Exec("CREATE TABLE keyed (a INTEGER, b INTEGER, label TEXT, PRIMARY KEY (b, a)) WITHOUT ROWID")
+	if err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+
+	keyColumns, err := GetPageKeyColumns(db, "plain")
+	if err != nil {
+		t.Fatalf("get key columns: %v", err)
+	}
+
+	if len(keyColumns) != 1 || keyColumns[0] != RowIDColumn {
+		t.Fatalf("expected rowid key, got %v", keyColumns)
+	}
+
+	keyColumns, err = GetPageKeyColumns(db, "keyed")
+	if err != nil {
+		t.Fatalf("get key columns: %v", err)
+	}
+
+	if len(keyColumns) != 2 || keyColumns[0] != "b" || keyColumns[1] != "a" {
+		t.Fatalf("expected composite key, got %v", keyColumns)
+	}
+}
+
+func TestQueryTablePage_KeysetMatchesOffset(t *testing.T) {
+	db := createTestDb(t)
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatalf("close db: %v", err)
+		}
+	}()
+
+	seedKeysetTable(t, db, "CREATE TABLE items (a INTEGER, b INTEGER, label TEXT)", 300)
+
+	_, err := db.Exec("DELETE FROM items WHERE b % 10 = 0")
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	assertKeysetPaging(t, db, []string{RowIDColumn})
+}
+
+func TestQueryTablePage_KeysetWithoutRowID(t *testing.T) {
+	db := createTestDb(t)
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatalf("close db: %v", err)
+		}
+	}()
+
+	seedKeysetTable(t, db, "CREATE TABLE items (a INTEGER, b INTEGER, label TEXT, PRIMARY KEY (a, b)) WITHOUT ROWID", 300)
+
+	keyColumns, err := GetPageKeyColumns(db, "items")
+	if err != nil {
+		t.Fatalf("get key columns: %v", err)
+	}
+
+	assertKeysetPaging(t, db, keyColumns)
+}
+
+func TestQueryTablePage_Where(t *testing.T) {
+	db := createTestDb(t)
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatalf("close db: %v", err)
+		}
+	}()
+
+	seedKeysetTable(t, db, "CREATE TABLE items (a INTEGER, b INTEGER, label TEXT)", 300)
+
+	anchors := []PageAnchor{}
+	for _, offset := range []int{0, 10, 0} {
+		page, err := QueryTablePage(db, TablePageQuery{
+			Table:        "items",
+			Limit:        10,
+			Offset:       offset,
+			IncludeRowID: false,
+			KeyColumns:   []string{RowIDColumn},
+			Anchors:      anchors,
+			Where:        "b % ? = 0",
+			WhereArgs:    []any{3},
+			OrderBy:      nil,
+			CountRows:    true,
+			TotalRows:    0,
+		})
+		if err != nil {
+			t.Fatalf("get page at %d: %v", offset, err)
+		}
+
+		if page.TotalRows != 100 {
+			t.Fatalf("expected filtered count of 100, got %d", page.TotalRows)
+		}
+
+		if len(page.Rows) != 10 {
+			t.Fatalf("offset %d: expected 10 rows, got %d", offset, len(page.Rows))
+		}
+
+		for _, row := range page.Rows {
+			if row["b"].(int64)%3 != 0 {
+				t.Fatalf("offset %d: row outside filter: %v", offset, row)
+			}
+		}
+
+		anchors = MergeAnchors(anchors, page.Anchors, 8)
+	}
+}
+
+func TestQueryTablePage_OrderBy(t *testing.T) {
+	db := createTestDb(t)
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatalf("close db: %v", err)
+		}
+	}()
+
+	seedKeysetTable(t, db, "CREATE TABLE items (a INTEGER, b INTEGER, label TEXT)", 300)
+
+	expected, err := QueryRows(db, "SELECT label FROM items ORDER BY a, b DESC", 0)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+
+	for _, offset := range []int{0, 150, 290} {
+		page, err := QueryTablePage(db, TablePageQuery{
+			Table:        "items",
+			Limit:        20,
+			Offset:       offset,
+			IncludeRowID: false,
+			KeyColumns:   []string{RowIDColumn},
+			Anchors:      nil,
+			Where:        "",
+			WhereArgs:    nil,
+			OrderBy:      []SortKey{{Column: "a", Descending: false}, {Column: "b", Descending: true}},
+			CountRows:    true,
+			TotalRows:    0,
+		})
+		if err != nil {
+			t.Fatalf("get page at %d: %v", offset, err)
+		}
+
+		if len(page.Anchors) != 0 {
+			t.Fatalf("expected no anchors for sorted pages, got %v", page.Anchors)
+		}
+
+		for i, row := range page.Rows {
+			want := expected.Rows[offset+i]["label"]
+			if row["label"] != want {
+				t.Fatalf("offset %d row %d: expected %v, got %v", offset, i, want, row["label"])
+			}
+		}
+	}
+}
+
+func TestQueryTablePage_OrderByDeepPagesWithTies(t *testing.T) {
+	db := createTestDb(t)
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatalf("close db: %v", err)
+		}
+	}()
+
+	seedKeysetTable(t, db, "CREATE TABLE items (a INTEGER, b INTEGER, label TEXT)", 300)
+
+	expected, err := QueryRows(db, "SELECT label FROM items ORDER BY a DESC, rowid", 0)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+
+	for _, offset := range []int{140, 200, 285, 299} {
+		page, err := QueryTablePage(db, TablePageQuery{
+			Table:        "items",
+			Limit:        20,
+			Offset:       offset,
+			IncludeRowID: false,
+			KeyColumns:   []string{RowIDColumn},
+			Anchors:      nil,
+			Where:        "",
+			WhereArgs:    nil,
+			OrderBy:      []SortKey{{Column: "a", Descending: true}},
+			CountRows:    false,
+			TotalRows:    300,
+		})
+		if err != nil {
+			t.Fatalf("get page at %d: %v", offset, err)
+		}
+
+		wantCount := min(20, 300-offset)
+		if len(page.Rows) != wantCount {
+			t.Fatalf("offset %d: expected %d rows, got %d", offset, wantCount, len(page.Rows))
+		}
+
+		for i, row := range page.Rows {
+			want := expected.Rows[offset+i]["label"]
+			if row["label"] != want {
+				t.Fatalf("offset %d row %d: expected %v, got %v", offset, i, want, row["label"])
+			}
+		}
+	}
+}
+
+func TestPlanKeysetPage_DeepTargetsSeekFromEnd(t *testing.T) {
+	keyColumns := []string{RowIDColumn}
+
+	plan := planKeysetPage(keyColumns, nil, 900000, 500, 1000000)
+	if !plan.descending || !plan.fromEnd || plan.offset != 900000 || plan.limit != 500 {
+		t.Fatalf("expected a reversed seek from the end, got %+v", plan)
+	}
+
+	plan = planKeysetPage(keyColumns, nil, 400000, 500, 1000000)
+	if plan.descending || plan.offset != 400000 {
+		t.Fatalf("expected a forward scan for the front half, got %+v", plan)
+	}
+
+	anchors := []PageAnchor{{Offset: 399000, Key: []SqliteValue{int64(420000)}}}
+	plan = planKeysetPage(keyColumns, anchors, 400000, 500, 1000000)
+	if plan.descending || plan.offset != 1000 || plan.where == "" {
+		t.Fatalf("expected a seek from the anchor, got %+v", plan)
+	}
+}
+
+func TestQueryTablePage_DeepPagesIgnoreStaleCount(t *testing.T) {
+	db := createTestDb(t)
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatalf("close db: %v", err)
+		}
+	}()
+
+	seedKeysetTable(t, db, "CREATE TABLE items (a INTEGER, b INTEGER, label TEXT)", 300)
+
+	_, err := db.Exec("DELETE FROM items WHERE rowid % 10 = 0")
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	for _, orderBy := range [][]SortKey{nil, {{Column: "a", Descending: true}}} {
+		expectedSql := "SELECT label FROM items ORDER BY rowid"
+		if orderBy != nil {
+			expectedSql = "SELECT label FROM items ORDER BY a DESC, rowid"
+		}
+		expected, err := QueryRows(db, expectedSql, 0)
+		if err != nil {
+			t.Fatalf("query: %v", err)
+		}
+
+		for _, totalRows := range []int{300, 250} {
+			for _, offset := range []int{200, 255, 265} {
+				page, err := QueryTablePage(db, TablePageQuery{
+					Table:        "items",
+					Limit:        20,
+					Offset:       offset,
+					IncludeRowID: false,
+					KeyColumns:   []string{RowIDColumn},
+					Anchors:      nil,
+					Where:        "",
+					WhereArgs:    nil,
+					OrderBy:      orderBy,
+					CountRows:    false,
+					TotalRows:    totalRows,
+				})
+				if err != nil {
+					t.Fatalf("get page at %d: %v", offset, err)
+				}
+
+				wantCount := max(0, min(20, len(expected.Rows)-offset))
+				if len(page.Rows) != wantCount {
+					t.Fatalf("count %d offset %d: expected %d rows, got %d", totalRows, offset, wantCount, len(page.Rows))
+				}
+
+				for i, row := range page.Rows {
+					want := expected.Rows[offset+i]["label"]
+					if row["label"] != want {
+						t.Fatalf("count %d offset %d row %d: expected %v, got %v", totalRows, offset, i, want, row["label"])
+					}
+				}
+			}
+		}
+	}
+}
+
+func assertKeysetPaging(t *testing.T, db *sql.DB, keyColumns []string) {
+	t.Helper()
+
+	totalRows, err := CountTableRows(db, "items")
+	if err != nil {
+		t.Fatalf("count: %v", err)
+	}
+
+	orderBy := keyOrderBy(keyColumns, false)
+	expected, err := QueryRows(db, "SELECT label FROM items ORDER BY "+orderBy, 0)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+
+	anchors := []PageAnchor{}
+	for _, offset := range []int{0, 25, 50, 40, 200, totalRows - 10, 120} {
+		page, err := QueryTablePage(db, TablePageQuery{
+			Table:        "items",
+			Limit:        25,
+			Offset:       offset,
+			IncludeRowID: false,
+			KeyColumns:   keyColumns,
+			Anchors:      anchors,
+			Where:        "",
+			WhereArgs:    nil,
+			OrderBy:      nil,
+			CountRows:    false,
+			TotalRows:    totalRows,
+		})
+		if err != nil {
+			t.Fatalf("get page at %d: %v", offset, err)
+		}
+
+		wantCount := min(25, totalRows-offset)
+		if len(page.Rows) != wantCount {
+			t.Fatalf("offset %d: expected %d rows, got %d", offset, wantCount, len(page.Rows))
+		}
+
+		for i, row := range page.Rows {
+			want := expected.Rows[offset+i]["label"]
+			if row["label"] != want {
+				t.Fatalf("offset %d row %d: expected %v, got %v", offset, i, want, row["label"])
+			}
+		}
+
+		anchors = MergeAnchors(anchors, page.Anchors, 8)
+	}
+
+	if len(anchors) > 8 {
+		t.Fatalf("expected anchors to be capped, got %d", len(anchors))
+	}
+}
+
+func TestTableSelectSQL(t *testing.T) {
+	db := createTestDb(t)
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatalf("close db: %v", err)
+		}
+	}()
+
+	seedKeysetTable(t, db, "CREATE TABLE items (a INTEGER, b INTEGER, label TEXT)", 30)
+
+	sqlText, args := TableSelectSQL("items", "b % ? = 0 OR b = 1", []any{10}, []SortKey{{Column: "a", Descending: true}}, []string{RowIDColumn})
+	result, err := QueryRows(db, sqlText, 0, args...)
+	if err != nil {
+		t.Fatalf("query %q: %v", sqlText, err)
+	}
+
+	labels := []string{}
+	for _, row := range result.Rows {
+		labels = append(labels, row["label"].(string))
+	}
+
+	want := []string{"item 20", "item 10", "item 30", "item 1"}
+	if !slices.Equal(labels, want) {
+		t.Fatalf("expected %v, got %v", want, labels)
+	}
+
+	if slices.Contains(result.Columns, RowIDColumn) {
+		t.Fatalf("expected table columns only, got %v", result.Columns)
+	}
+}
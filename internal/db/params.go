@@ -0,0 +1,61 @@
+package db
+
+import (
+	"database/sql"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"squlito/internal/sqlsyntax"
+)
+
+func ParseLiteral(text string) SqliteValue {
+	trimmed := strings.TrimSpace(text)
+	if strings.EqualFold(trimmed, "NULL") {
+		return nil
+	}
+
+	integer, err := strconv.ParseInt(trimmed, 10, 64)
+	if err == nil {
+		return integer
+	}
+
+	number, err := strconv.ParseFloat(trimmed, 64)
+	if err == nil && !math.IsInf(number, 0) && !math.IsNaN(number) {
+		return number
+	}
+
+	if len(trimmed) >= 2 && strings.HasPrefix(trimmed, "'") && strings.HasSuffix(trimmed, "'") {
+		return strings.ReplaceAll(trimmed[1:len(trimmed)-1], "''", "'")
+	}
+
+	return text
+}
+
+func BindParameters(parameters []sqlsyntax.Parameter, values map[string]string) []any {
+	highest := 0
+	for _, parameter := range parameters {
+		if parameter.Index <= sqlsyntax.MaxParameterIndex {
+			highest = max(highest, parameter.Index)
+		}
+	}
+
+	args := make([]any, highest)
+	named := []any{}
+	for _, parameter := range parameters {
+		if parameter.Index < 1 || parameter.Index > sqlsyntax.MaxParameterIndex {
+			continue
+		}
+
+		value := ParseLiteral(values[parameter.Name])
+		args[parameter.Index-1] = value
+
+		name := parameter.Name[1:]
+		if parameter.Name[0] != '?' && unicode.IsLetter([]rune(name)[0]) {
+			named = append(named, sql.Named(name, value))
+		}
+	}
+
+	return append(args, named...)
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"squlito/internal/sqlsyntax"
+)
+
+func TestParseLiteral(t *testing.T) {
+	tests := []struct {
+		text string
+		want SqliteValue
+	}{
+		{text: "NULL", want: nil},
+		{text: " null ", want: nil},
+		{text: "42", want: int64(42)},
+		{text: "-7", want: int64(-7)},
+		{text: "1.5", want: 1.5},
+		{text: "1e3", want: 1000.0},
+		{text: "'it''s'", want: "it's"},
+		{text: "'42'", want: "42"},
+		{text: "hello", want: "hello"},
+		{text: "inf", want: "inf"},
+		{text: "", want: ""},
+	}
+
+	for _, test := range tests {
+		if got := ParseLiteral(test.text); got != test.want {
+			t.Fatalf("ParseLiteral(%q) = %#v, want %#v", test.text, got, test.want)
+		}
+	}
+}
+
+func TestBindParameters(t *testing.T) {
+	db := createTestDb(t)
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatalf("close db: %v", err)
+		}
+	}()
+
+	sqlText := "SELECT ? AS a, :id AS b, @name AS c, ?5 AS d, :id + 1 AS e, typeof(?) AS f"
+	parameters, err := sqlsyntax.Parameters(sqlText)
+	if err != nil {
+		t.Fatalf("parameters: %v", err)
+	}
+	values := map[string]string{
+		"?1":    "x",
+		":id":   "41",
+		"@name": "'Ada'",
+		"?5":    "2.5",
+		"?6":    "NULL",
+	}
+
+	cursor, err := OpenQueryCursor(context.Background(), db, sqlText, BindParameters(parameters, values)...)
+	if err != nil {
+		t.Fatalf("open cursor: %v", err)
+	}
+	defer func() {
+		err := cursor.Close()
+		if err != nil {
+			t.Fatalf("close cursor: %v", err)
+		}
+	}()
+
+	rows, err := cursor.Fetch(1)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+
+	row := rows[0]
+	if row["a"] != "x" || row["b"] != int64(41) || row["c"] != "Ada" || row["d"] != 2.5 || row["e"] != int64(42) || row["f"] != "null" {
+		t.Fatalf("unexpected row %#v", row)
+	}
+}
+
+func TestBindParametersIgnoresOutOfRangeIndexes(t *testing.T) {
+	parameters := []sqlsyntax.Parameter{
+		{Name: "?0", Index: 0},
+		{Name: "?999999999", Index: 999999999},
+		{Name: "?2", Index: 2},
+	}
+
+	args := BindParameters(parameters, map[string]string{"?2": "7"})
+	if len(args) != 2 || args[0] != nil || args[1] != int64(7) {
+		t.Fatalf("unexpected args %#v", args)
+	}
+}
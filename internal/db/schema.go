@@ -0,0 +1,70 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+)
+
+const (
+	ObjectTable        = "table"
+	ObjectVirtualTable = "virtual table"
+	ObjectView         = "view"
+	ObjectIndex        = "index"
+	ObjectTrigger      = "trigger"
+)
+
+type SchemaObject struct {
+	Name      string
+	Type      string
+	TableName string
+	SQL       string
+}
+
+func ListSchemaObjects(db *sql.DB) (objects []SchemaObject, err error) {
+	sqlText := "SELECT type, name, tbl_name, sql FROM sqlite_master WHERE type IN ('table', 'view', 'index', 'trigger') AND name NOT LIKE 'sqlite_%' ORDER BY name"
+	rows, err := db.Query(sqlText)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		closeErr := rows.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	objects = []SchemaObject{}
+	for rows.Next() {
+		var objectType string
+		var name string
+		var tableName string
+		var sqlText sql.NullString
+		err = rows.Scan(&objectType, &name, &tableName, &sqlText)
+		if err != nil {
+			return nil, err
+		}
+
+		if objectType == ObjectTable && isVirtualTableSql(sqlText.String) {
+			objectType = ObjectVirtualTable
+		}
+
+		objects = append(objects, SchemaObject{
+			Name:      name,
+			Type:      objectType,
+			TableName: tableName,
+			SQL:       sqlText.String,
+		})
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+func isVirtualTableSql(sqlText string) bool {
+	fields := strings.Fields(strings.ToUpper(sqlText))
+	return len(fields) >= 2 && fields[0] == "CREATE" && fields[1] == "VIRTUAL"
+}
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"testing"
+)
+
+func TestListSchemaObjects(t *testing.T) {
+	db := createTestDb(t)
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatalf("close db: %v", err)
+		}
+	}()
+
+	statements := []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT UNIQUE, name TEXT)",
+		"CREATE INDEX users_name ON users (name)",
+		"CREATE VIEW active_users AS SELECT * FROM users",
+		"CREATE TRIGGER users_touch AFTER UPDATE ON users BEGIN SELECT 1; END",
+		"CREATE VIRTUAL TABLE search USING fts5(body)",
+	}
+	for _, statement := range statements {
+		_, err := db.Exec(statement)
+		if err != nil {
+			t.Fatalf("exec %q: %v", statement, err)
+		}
+	}
+
+	objects, err := ListSchemaObjects(db)
+	if err != nil {
+		t.Fatalf("list objects: %v", err)
+	}
+
+	byName := map[string]SchemaObject{}
+	for _, object := range objects {
+		byName[object.Name] = object
+	}
+
+	expected := map[string]string{
+		"users":        ObjectTable,
+		"users_name":   ObjectIndex,
+		"active_users": ObjectView,
+		"users_touch":  ObjectTrigger,
+		"search":       ObjectVirtualTable,
+	}
+	for name, objectType := range expected {
+		object, ok := byName[name]
+		if !ok {
+			t.Fatalf("missing schema object %q in %v", name, objects)
+		}
+		if object.Type != objectType {
+			t.Fatalf("expected %q to be %q, got %q", name, objectType, object.Type)
+		}
+	}
+
+	if byName["users_name"].TableName != "users" || byName["users_name"].SQL == "" {
+		t.Fatalf("unexpected index object: %v", byName["users_name"])
+	}
+
+	if _, ok := byName["sqlite_autoindex_users_1"]; ok {
+		t.Fatalf("expected internal autoindex to be hidden")
+	}
+}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+
+	"squlito/internal/sqlsyntax"
+)
+
+type Script struct {
+	conn          *sql.Conn
+	inTransaction bool
+}
+
+func OpenScript(ctx context.Context, db *sql.DB) (*Script, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Script{conn: conn, inTransaction: false}, nil
+}
+
+func (script *Script) Exec(ctx context.Context, sqlText string) (int64, error) {
+	result, err := script.conn.ExecContext(ctx, sqlText)
+	if err != nil {
+		return 0, err
+	}
+
+	script.trackTransaction(sqlText)
+	affected, _ := result.RowsAffected()
+	return affected, nil
+}
+
+func (script *Script) CountRows(ctx context.Context, sqlText string) (count int, err error) {
+	rows, err := script.conn.QueryContext(ctx, sqlText)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		closeErr := rows.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	for rows.Next() {
+		count += 1
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return 0, err
+	}
+
+	script.trackTransaction(sqlText)
+	return count, nil
+}
+
+func (script *Script) Query(ctx context.Context, sqlText string, release bool) (*QueryCursor, error) {
+	rows, err := script.conn.QueryContext(ctx, sqlText)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := newQueryCursor(rows)
+	if err != nil {
+		_ = rows.Close()
+		return nil, err
+	}
+
+	script.trackTransaction(sqlText)
+	if release {
+		cursor.release = script.Close
+	}
+	return cursor, nil
+}
+
+func (script *Script) InTransaction() bool {
+	return script.inTransaction
+}
+
+func (script *Script) Close() error {
+	if script.inTransaction {
+		_, _ = script.conn.ExecContext(context.Background(), "ROLLBACK")
+		script.inTransaction = false
+	}
+
+	return script.conn.Close()
+}
+
+func (script *Script) trackTransaction(sqlText string) {
+	words := []string{}
+	for _, token := range sqlsyntax.Tokenize(sqlText) {
+		if token.Kind == sqlsyntax.TokenKeyword || token.Kind == sqlsyntax.TokenIdentifier {
+			words = append(words, strings.ToUpper(token.Text))
+		}
+		if len(words) == 3 {
+			break
+		}
+	}
+	if len(words) == 0 {
+		return
+	}
+
+	switch words[0] {
+	case "BEGIN":
+		script.inTransaction = true
+	case "COMMIT", "END":
+		script.inTransaction = false
+	case "ROLLBACK":
+		if !slices.Contains(words[1:], "TO") {
+			script.inTransaction = false
+		}
+	}
+}
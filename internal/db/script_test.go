@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestScript_RunsStatementsOnOneConnection(t *testing.T) {
+	db, err := OpenOrCreateDatabase(filepath.Join(t.TempDir(), "script.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatalf("close db: %v", err)
+		}
+	}()
+
+	ctx := context.Background()
+	script, err := OpenScript(ctx, db)
+	if err != nil {
+		t.Fatalf("open script: %v", err)
+	}
+
+	_, err = script.Exec(ctx, "CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT)")
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	_, err = script.Exec(ctx, "BEGIN")
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	affected, err := script.Exec(ctx, "INSERT INTO items (name) VALUES ('a'), ('b'), ('c')")
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if affected != 3 || !script.InTransaction() {
+		t.Fatalf("expected 3 affected rows in a transaction, got %d %v", affected, script.InTransaction())
+	}
+
+	count, err := script.CountRows(ctx, "SELECT * FROM items")
+	if err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if count != 3 {
+		t.Fatalf("expected 3 rows inside the transaction, got %d", count)
+	}
+
+	_, err = script.Exec(ctx, "SAVEPOINT inner")
+	if err != nil {
+		t.Fatalf("savepoint: %v", err)
+	}
+	_, err = script.Exec(ctx, "ROLLBACK TO inner")
+	if err != nil {
+		t.Fatalf("rollback to: %v", err)
+	}
+	if !script.InTransaction() {
+		t.Fatalf("ROLLBACK TO should keep the transaction open")
+	}
+
+	cursor, err := script.Query(ctx, "SELECT name FROM items ORDER BY id", true)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	rows, err := cursor.Fetch(0)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(rows) != 3 || rows[0]["name"] != "a" {
+		t.Fatalf("unexpected rows: %v", rows)
+	}
+
+	err = cursor.Close()
+	if err != nil {
+		t.Fatalf("close cursor: %v", err)
+	}
+
+	var remaining int
+	err = db.QueryRow("SELECT count(*) FROM items").Scan(&remaining)
+	if err != nil {
+		t.Fatalf("count after close: %v", err)
+	}
+	if remaining != 0 {
+		t.Fatalf("expected the open transaction to be rolled back, got %d rows", remaining)
+	}
+}
@@ -0,0 +1,162 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type RowSpill struct {
+	conn    *sql.DB
+	path    string
+	columns []string
+}
+
+func OpenRowSpill(columns []string) (*RowSpill, error) {
+	file, err := os.CreateTemp("", "squlito-rows-*.db")
+	if err != nil {
+		return nil, err
+	}
+
+	path := file.Name()
+	err = file.Close()
+	if err != nil {
+		_ = os.Remove(path)
+		return nil, err
+	}
+
+	conn, err := OpenOrCreateDatabase(path)
+	if err != nil {
+		_ = os.Remove(path)
+		return nil, err
+	}
+	conn.SetMaxOpenConns(1)
+
+	spill := &RowSpill{conn: conn, path: path, columns: columns}
+	createTable := "CREATE TABLE spilled_rows (position INTEGER PRIMARY KEY"
+	for index := range columns {
+		createTable += ", " + spillColumn(index)
+	}
+	createTable += ")"
+
+	for _, statement := range []string{"PRAGMA journal_mode = OFF", "PRAGMA synchronous = OFF", createTable} {
+		_, err = conn.Exec(statement)
+		if err != nil {
+			_ = spill.Close()
+			return nil, err
+		}
+	}
+
+	return spill, nil
+}
+
+func (spill *RowSpill) Write(start int, rows []SqliteRow) (err error) {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	placeholders := strings.Repeat(", ?", len(spill.columns))
+	tx, err := spill.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	statement, err := tx.Prepare("INSERT OR REPLACE INTO spilled_rows VALUES (?" + placeholders + ")")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = statement.Close()
+	}()
+
+	args := make([]any, len(spill.columns)+1)
+	for offset, row := range rows {
+		args[0] = start + offset
+		for index, column := range spill.columns {
+			args[index+1] = row[column]
+		}
+
+		_, err = statement.Exec(args...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (spill *RowSpill) Read(start int, limit int) ([]SqliteRow, error) {
+	columns := []string{}
+	for index := range spill.columns {
+		columns = append(columns, spillColumn(index))
+	}
+
+	query := "SELECT position"
+	if len(columns) > 0 {
+		query += ", " + strings.Join(columns, ", ")
+	}
+	query += " FROM spilled_rows WHERE position >= ? ORDER BY position LIMIT ?"
+
+	rows, err := spill.conn.Query(query, start, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var position int
+	values := make([]any, len(spill.columns))
+	valuePtrs := []any{&position}
+	for index := range values {
+		valuePtrs = append(valuePtrs, &values[index])
+	}
+
+	result := []SqliteRow{}
+	for rows.Next() {
+		err = rows.Scan(valuePtrs...)
+		if err != nil {
+			return nil, err
+		}
+		if position != start+len(result) {
+			break
+		}
+
+		row := make(SqliteRow, len(spill.columns))
+		for index, column := range spill.columns {
+			row[column] = normalizeValue(values[index])
+		}
+		result = append(result, row)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (spill *RowSpill) Close() error {
+	if spill == nil {
+		return nil
+	}
+
+	err := spill.conn.Close()
+	removeErr := os.Remove(spill.path)
+	if err == nil && !errors.Is(removeErr, os.ErrNotExist) {
+		err = removeErr
+	}
+	return err
+}
+
+func spillColumn(index int) string {
+	return "c" + strconv.Itoa(index)
+}
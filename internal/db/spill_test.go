@@ -0,0 +1,63 @@
+package db
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestRowSpill_RoundTrip(t *testing.T) {
+	spill, err := OpenRowSpill([]string{"id", "name", "score", "data"})
+	if err != nil {
+		t.Fatalf("open spill: %v", err)
+	}
+
+	first := []SqliteRow{
+		{"id": int64(1), "name": "ada", "score": 1.5, "data": []byte{0, 1}},
+		{"id": int64(2), "name": nil, "score": int64(3), "data": "text"},
+	}
+	err = spill.Write(0, first)
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	err = spill.Write(2, []SqliteRow{{"id": int64(3), "name": "eve", "score": nil, "data": nil}})
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	rows, err := spill.Read(1, 10)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(rows) != 2 || rows[0]["id"] != int64(2) || rows[0]["name"] != nil || rows[0]["score"] != int64(3) || rows[0]["data"] != "text" || rows[1]["name"] != "eve" {
+		t.Fatalf("unexpected rows %#v", rows)
+	}
+
+	rows, err = spill.Read(0, 1)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	data, ok := rows[0]["data"].([]byte)
+	if len(rows) != 1 || rows[0]["score"] != 1.5 || !ok || !bytes.Equal(data, []byte{0, 1}) {
+		t.Fatalf("expected typed values to survive, got %#v", rows)
+	}
+
+	err = spill.Write(5, []SqliteRow{{"id": int64(6), "name": "gap", "score": nil, "data": nil}})
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	rows, err = spill.Read(2, 10)
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("expected reading to stop at the gap, got %#v (%v)", rows, err)
+	}
+
+	path := spill.path
+	err = spill.Close()
+	if err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	_, err = os.Stat(path)
+	if !os.IsNotExist(err) {
+		t.Fatalf("expected the spill file to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,354 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+const (
+	ColumnVisible          = 0
+	ColumnHidden           = 1
+	ColumnGeneratedVirtual = 2
+	ColumnGeneratedStored  = 3
+)
+
+type StructureColumn struct {
+	SqliteColumn
+	Hidden int
+}
+
+type ForeignKey struct {
+	ID       int
+	Table    string
+	From     []string
+	To       []string
+	OnUpdate string
+	OnDelete string
+}
+
+type TableIndex struct {
+	Name    string
+	Unique  bool
+	Origin  string
+	Partial bool
+	Columns []string
+}
+
+type InboundReference struct {
+	Table      string
+	ForeignKey ForeignKey
+}
+
+type TableStructure struct {
+	Name        string
+	Columns     []StructureColumn
+	ForeignKeys []ForeignKey
+	Indexes     []TableIndex
+	SQL         string
+}
+
+func GetTableStructure(db *sql.DB, tableName string) (TableStructure, error) {
+	structure := TableStructure{
+		Name:        tableName,
+		Columns:     nil,
+		ForeignKeys: nil,
+		Indexes:     nil,
+		SQL:         "",
+	}
+
+	columns, err := GetStructureColumns(db, tableName)
+	if err != nil {
+		return structure, err
+	}
+
+	foreignKeys, err := GetForeignKeys(db, tableName)
+	if err != nil {
+		return structure, err
+	}
+
+	indexes, err := GetTableIndexes(db, tableName)
+	if err != nil {
+		return structure, err
+	}
+
+	var sqlText sql.NullString
+	err = db.QueryRow("SELECT sql FROM sqlite_master WHERE name = ?", tableName).Scan(&sqlText)
+	if err != nil && err != sql.ErrNoRows {
+		return structure, err
+	}
+
+	structure.Columns = columns
+	structure.ForeignKeys = foreignKeys
+	structure.Indexes = indexes
+	structure.SQL = sqlText.String
+	return structure, nil
+}
+
+func GetStructureColumns(db *sql.DB, tableName string) (columns []StructureColumn, err error) {
+	sqlText := fmt.Sprintf("PRAGMA table_xinfo(%s)", quoteIdentifier(tableName))
+	rows, err := db.Query(sqlText)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		closeErr := rows.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	columns = []StructureColumn{}
+	for rows.Next() {
+		column := StructureColumn{
+			SqliteColumn: SqliteColumn{
+				Cid:          0,
+				Name:         "",
+				Type:         "",
+				NotNull:      0,
+				DefaultValue: sql.NullString{String: "", Valid: false},
+				PrimaryKey:   0,
+			},
+			Hidden: 0,
+		}
+		err = rows.Scan(
+			&column.Cid,
+			&column.Name,
+			&column.Type,
+			&column.NotNull,
+			&column.DefaultValue,
+			&column.PrimaryKey,
+			&column.Hidden,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		columns = append(columns, column)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return columns, nil
+}
+
+func GetForeignKeys(db *sql.DB, tableName string) ([]ForeignKey, error) {
+	foreignKeys, err := readForeignKeys(db, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, foreignKey := range foreignKeys {
+		if !hasImplicitTarget(foreignKey) {
+			continue
+		}
+
+		parentColumns, err := GetTableColumns(db, foreignKey.Table)
+		if err != nil {
+			return nil, err
+		}
+
+		parentKey := primaryKeyColumns(parentColumns)
+		if len(parentKey) == len(foreignKey.From) {
+			foreignKeys[i].To = parentKey
+		}
+	}
+
+	return foreignKeys, nil
+}
+
+func ListInboundReferences(db *sql.DB, tableName string) ([]InboundReference, error) {
+	tables, err := ListUserTables(db)
+	if err != nil {
+		return nil, err
+	}
+
+	references := []InboundReference{}
+	for _, table := range tables {
+		foreignKeys, err := GetForeignKeys(db, table.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, foreignKey := range foreignKeys {
+			if strings.EqualFold(foreignKey.Table, tableName) {
+				references = append(references, InboundReference{Table: table.Name, ForeignKey: foreignKey})
+			}
+		}
+	}
+
+	return references, nil
+}
+
+func readForeignKeys(db *sql.DB, tableName string) (foreignKeys []ForeignKey, err error) {
+	sqlText := fmt.Sprintf("PRAGMA foreign_key_list(%s)", quoteIdentifier(tableName))
+	rows, err := db.Query(sqlText)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		closeErr := rows.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	foreignKeys = []ForeignKey{}
+	for rows.Next() {
+		var id int
+		var seq int
+		var table string
+		var from string
+		var to sql.NullString
+		var onUpdate string
+		var onDelete string
+		var match string
+		err = rows.Scan(&id, &seq, &table, &from, &to, &onUpdate, &onDelete, &match)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(foreignKeys) == 0 || foreignKeys[len(foreignKeys)-1].ID != id {
+			foreignKeys = append(foreignKeys, ForeignKey{
+				ID:       id,
+				Table:    table,
+				From:     []string{},
+				To:       []string{},
+				OnUpdate: onUpdate,
+				OnDelete: onDelete,
+			})
+		}
+
+		last := &foreignKeys[len(foreignKeys)-1]
+		last.From = append(last.From, from)
+		last.To = append(last.To, to.String)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return foreignKeys, nil
+}
+
+func hasImplicitTarget(foreignKey ForeignKey) bool {
+	for _, column := range foreignKey.To {
+		if column == "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func GetTableIndexes(db *sql.DB, tableName string) ([]TableIndex, error) {
+	indexes, err := readIndexList(db, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, index := range indexes {
+		columns, err := readIndexColumns(db, index.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		indexes[i].Columns = columns
+	}
+
+	return indexes, nil
+}
+
+func readIndexList(db *sql.DB, tableName string) (indexes []TableIndex, err error) {
+	sqlText := fmt.Sprintf("PRAGMA index_list(%s)", quoteIdentifier(tableName))
+	rows, err := db.Query(sqlText)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		closeErr := rows.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	indexes = []TableIndex{}
+	for rows.Next() {
+		var seq int
+		var name string
+		var unique int
+		var origin string
+		var partial int
+		err = rows.Scan(&seq, &name, &unique, &origin, &partial)
+		if err != nil {
+			return nil, err
+		}
+
+		indexes = append(indexes, TableIndex{
+			Name:    name,
+			Unique:  unique == 1,
+			Origin:  origin,
+			Partial: partial == 1,
+			Columns: nil,
+		})
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return indexes, nil
+}
+
+func readIndexColumns(db *sql.DB, indexName string) (columns []string, err error) {
+	sqlText := fmt.Sprintf("PRAGMA index_xinfo(%s)", quoteIdentifier(indexName))
+	rows, err := db.Query(sqlText)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		closeErr := rows.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	columns = []string{}
+	for rows.Next() {
+		var seqno int
+		var cid int
+		var name sql.NullString
+		var desc int
+		var collation sql.NullString
+		var key int
+		err = rows.Scan(&seqno, &cid, &name, &desc, &collation, &key)
+		if err != nil {
+			return nil, err
+		}
+
+		if key == 0 {
+			continue
+		}
+
+		column := name.String
+		if cid == -2 {
+			column = "<expr>"
+		}
+		if desc == 1 {
+			column += " DESC"
+		}
+		columns = append(columns, column)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return columns, nil
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGetTableStructure(t *testing.T) {
+	db := createTestDb(t)
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatalf("close db: %v", err)
+		}
+	}()
+
+	statements := []string{
+		"CREATE TABLE customers (id INTEGER PRIMARY KEY, email TEXT NOT NULL UNIQUE)",
+		"CREATE TABLE orders (id INTEGER PRIMARY KEY, customer_id INTEGER REFERENCES customers ON DELETE CASCADE, status TEXT DEFAULT 'new', total REAL, total_cents INTEGER GENERATED ALWAYS AS (total * 100) VIRTUAL)",
+		"CREATE INDEX orders_status ON orders (status, total DESC)",
+	}
+	for _, statement := range statements {
+		_, err := db.Exec(statement)
+		if err != nil {
+			t.Fatalf("exec %q: %v", statement, err)
+		}
+	}
+
+	structure, err := GetTableStructure(db, "orders")
+	if err != nil {
+		t.Fatalf("get structure: %v", err)
+	}
+
+	if len(structure.Columns) != 5 {
+		t.Fatalf("expected 5 columns, got %v", structure.Columns)
+	}
+	if structure.Columns[0].PrimaryKey != 1 {
+		t.Fatalf("expected id to be the primary key, got %v", structure.Columns[0])
+	}
+	if structure.Columns[2].DefaultValue.String != "'new'" {
+		t.Fatalf("expected status default, got %v", structure.Columns[2].DefaultValue)
+	}
+	if structure.Columns[4].Hidden != ColumnGeneratedVirtual {
+		t.Fatalf("expected generated column, got %v", structure.Columns[4])
+	}
+
+	if len(structure.ForeignKeys) != 1 {
+		t.Fatalf("expected one foreign key, got %v", structure.ForeignKeys)
+	}
+	foreignKey := structure.ForeignKeys[0]
+	if foreignKey.Table != "customers" || !slices.Equal(foreignKey.From, []string{"customer_id"}) || !slices.Equal(foreignKey.To, []string{"id"}) {
+		t.Fatalf("unexpected foreign key: %v", foreignKey)
+	}
+	if foreignKey.OnDelete != "CASCADE" {
+		t.Fatalf("expected cascade delete, got %q", foreignKey.OnDelete)
+	}
+
+	if len(structure.Indexes) != 1 {
+		t.Fatalf("expected one index, got %v", structure.Indexes)
+	}
+	if !slices.Equal(structure.Indexes[0].Columns, []string{"status", "total DESC"}) {
+		t.Fatalf("unexpected index columns: %v", structure.Indexes[0].Columns)
+	}
+
+	if structure.SQL == "" {
+		t.Fatalf("expected CREATE TABLE sql")
+	}
+
+	customers, err := GetTableStructure(db, "customers")
+	if err != nil {
+		t.Fatalf("get structure: %v", err)
+	}
+	if len(customers.Indexes) != 1 || !customers.Indexes[0].Unique || customers.Indexes[0].Origin != "u" {
+		t.Fatalf("expected unique autoindex, got %v", customers.Indexes)
+	}
+}
+
+func TestListInboundReferences(t *testing.T) {
+	db := createTestDb(t)
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatalf("close db: %v", err)
+		}
+	}()
+
+	statements := []string{
+		"CREATE TABLE customers (id INTEGER PRIMARY KEY, name TEXT)",
+		"CREATE TABLE orders (id INTEGER PRIMARY KEY, customer_id INTEGER REFERENCES customers(id))",
+		"CREATE TABLE notes (id INTEGER PRIMARY KEY, author_id INTEGER REFERENCES Customers, reviewer_id INTEGER REFERENCES customers(id))",
+		"CREATE TABLE unrelated (id INTEGER PRIMARY KEY)",
+	}
+	for _, statement := range statements {
+		_, err := db.Exec(statement)
+		if err != nil {
+			t.Fatalf("exec %q: %v", statement, err)
+		}
+	}
+
+	references, err := ListInboundReferences(db, "customers")
+	if err != nil {
+		t.Fatalf("list references: %v", err)
+	}
+
+	found := []string{}
+	for _, reference := range references {
+		found = append(found, reference.Table+"."+strings.Join(reference.ForeignKey.From, ",")+"->"+strings.Join(reference.ForeignKey.To, ","))
+	}
+	slices.Sort(found)
+
+	expected := []string{"notes.author_id->id", "notes.reviewer_id->id", "orders.customer_id->id"}
+	if !slices.Equal(found, expected) {
+		t.Fatalf("expected %v, got %v", expected, found)
+	}
+}
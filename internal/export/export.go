@@ -0,0 +1,365 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"squlito/internal/db"
+	"squlito/internal/tableformat"
+)
+
+type Format string
+
+const (
+	FormatTable    Format = "table"
+	FormatCSV      Format = "csv"
+	FormatTSV      Format = "tsv"
+	FormatJSON     Format = "json"
+	FormatNDJSON   Format = "ndjson"
+	FormatMarkdown Format = "markdown"
+	FormatSQL      Format = "sql"
+)
+
+var Formats = []Format{FormatTable, FormatCSV, FormatTSV, FormatJSON, FormatNDJSON, FormatMarkdown, FormatSQL}
+
+const ResultTableName = "result"
+
+var extensionFormats = map[string]Format{
+	".csv":      FormatCSV,
+	".tsv":      FormatTSV,
+	".json":     FormatJSON,
+	".ndjson":   FormatNDJSON,
+	".jsonl":    FormatNDJSON,
+	".md":       FormatMarkdown,
+	".markdown": FormatMarkdown,
+	".sql":      FormatSQL,
+}
+
+type RowWriter interface {
+	WriteRow(row db.SqliteRow) error
+	Close() error
+}
+
+func ParseFormat(value string) (Format, error) {
+	normalized := Format(strings.ToLower(strings.TrimSpace(value)))
+	for _, format := range Formats {
+		if format == normalized {
+			return format, nil
+		}
+	}
+
+	names := []string{}
+	for _, format := range Formats {
+		names = append(names, string(format))
+	}
+	return "", fmt.Errorf("unknown format %q (expected one of %s)", value, strings.Join(names, ", "))
+}
+
+func FormatFromPath(path string) (Format, bool) {
+	format, ok := extensionFormats[strings.ToLower(filepath.Ext(path))]
+	return format, ok
+}
+
+func NewWriter(writer io.Writer, format Format, tableName string, columns []string) (RowWriter, error) {
+	switch format {
+	case FormatTable:
+		return &tableWriter{writer: writer, columns: columns, rows: []db.SqliteRow{}}, nil
+	case FormatCSV:
+		return newDelimitedWriter(writer, columns, ',')
+	case FormatTSV:
+		return newTSVWriter(writer, columns)
+	case FormatJSON:
+		return &jsonWriter{writer: writer, columns: columns, count: 0, ndjson: false}, nil
+	case FormatNDJSON:
+		return &jsonWriter{writer: writer, columns: columns, count: 0, ndjson: true}, nil
+	case FormatMarkdown:
+		return newMarkdownWriter(writer, columns)
+	case FormatSQL:
+		return &sqlWriter{writer: writer, tableName: tableName, columns: columns}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
+
+func WriteRows(writer io.Writer, format Format, tableName string, columns []string, rows []db.SqliteRow) error {
+	rowWriter, err := NewWriter(writer, format, tableName, columns)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		err = rowWriter.WriteRow(row)
+		if err != nil {
+			return err
+		}
+	}
+
+	return rowWriter.Close()
+}
+
+type tableWriter struct {
+	writer  io.Writer
+	columns []string
+	rows    []db.SqliteRow
+}
+
+func (table *tableWriter) WriteRow(row db.SqliteRow) error {
+	table.rows = append(table.rows, row)
+	return nil
+}
+
+func (table *tableWriter) Close() error {
+	render := tableformat.ComputeTable(tableformat.ComputeTableConfig{
+		Columns:      table.columns,
+		ColumnLabels: nil,
+		Rows:         table.rows,
+		MaxRows:      0,
+		CellStyle:    nil,
+		Highlight:    "",
+	})
+
+	output := render.Header + "\n" + strings.Repeat("-", render.Width) + "\n"
+	if render.Body != "" {
+		output += render.Body + "\n"
+	}
+
+	_, err := io.WriteString(table.writer, output)
+	return err
+}
+
+type delimitedWriter struct {
+	writer  *csv.Writer
+	columns []string
+}
+
+func newDelimitedWriter(writer io.Writer, columns []string, delimiter rune) (RowWriter, error) {
+	csvWriter := csv.NewWriter(writer)
+	csvWriter.Comma = delimiter
+
+	err := csvWriter.Write(columns)
+	if err != nil {
+		return nil, err
+	}
+
+	return &delimitedWriter{writer: csvWriter, columns: columns}, nil
+}
+
+func (delimited *delimitedWriter) WriteRow(row db.SqliteRow) error {
+	record := []string{}
+	for _, column := range delimited.columns {
+		record = append(record, textValue(row[column]))
+	}
+
+	return delimited.writer.Write(record)
+}
+
+func (delimited *delimitedWriter) Close() error {
+	delimited.writer.Flush()
+	return delimited.writer.Error()
+}
+
+type tsvWriter struct {
+	writer  io.Writer
+	columns []string
+}
+
+var tsvEscaper = strings.NewReplacer("\\", "\\\\", "\t", "\\t", "\n", "\\n", "\r", "\\r")
+
+func newTSVWriter(writer io.Writer, columns []string) (RowWriter, error) {
+	tsv := &tsvWriter{writer: writer, columns: columns}
+	err := tsv.writeLine(columns)
+	if err != nil {
+		return nil, err
+	}
+
+	return tsv, nil
+}
+
+func (tsv *tsvWriter) WriteRow(row db.SqliteRow) error {
+	fields := []string{}
+	for _, column := range tsv.columns {
+		fields = append(fields, textValue(row[column]))
+	}
+
+	return tsv.writeLine(fields)
+}
+
+func (tsv *tsvWriter) writeLine(fields []string) error {
+	escaped := []string{}
+	for _, field := range fields {
+		escaped = append(escaped, tsvEscaper.Replace(field))
+	}
+
+	_, err := io.WriteString(tsv.writer, strings.Join(escaped, "\t")+"\n")
+	return err
+}
+
+func (tsv *tsvWriter) Close() error {
+	return nil
+}
+
+type jsonWriter struct {
+	writer  io.Writer
+	columns []string
+	count   int
+	ndjson  bool
+}
+
+func (writer *jsonWriter) WriteRow(row db.SqliteRow) error {
+	encoded, err := encodeJSONRow(writer.columns, row)
+	if err != nil {
+		return err
+	}
+
+	prefix := ""
+	if !writer.ndjson {
+		prefix = ",\n  "
+		if writer.count == 0 {
+			prefix = "[\n  "
+		}
+	}
+
+	writer.count += 1
+	suffix := ""
+	if writer.ndjson {
+		suffix = "\n"
+	}
+
+	_, err = io.WriteString(writer.writer, prefix+encoded+suffix)
+	return err
+}
+
+func (writer *jsonWriter) Close() error {
+	if writer.ndjson {
+		return nil
+	}
+
+	closing := "\n]\n"
+	if writer.count == 0 {
+		closing = "[]\n"
+	}
+
+	_, err := io.WriteString(writer.writer, closing)
+	return err
+}
+
+type markdownWriter struct {
+	writer  io.Writer
+	columns []string
+}
+
+var markdownEscaper = strings.NewReplacer("\\", "\\\\", "|", "\\|", "\r\n", "<br>", "\n", "<br>", "\r", "<br>")
+
+func newMarkdownWriter(writer io.Writer, columns []string) (RowWriter, error) {
+	markdown := &markdownWriter{writer: writer, columns: columns}
+	err := markdown.writeLine(columns)
+	if err != nil {
+		return nil, err
+	}
+
+	separator := []string{}
+	for range columns {
+		separator = append(separator, "---")
+	}
+
+	_, err = io.WriteString(writer, "| "+strings.Join(separator, " | ")+" |\n")
+	if err != nil {
+		return nil, err
+	}
+
+	return markdown, nil
+}
+
+func (markdown *markdownWriter) WriteRow(row db.SqliteRow) error {
+	fields := []string{}
+	for _, column := range markdown.columns {
+		fields = append(fields, textValue(row[column]))
+	}
+
+	return markdown.writeLine(fields)
+}
+
+func (markdown *markdownWriter) writeLine(fields []string) error {
+	escaped := []string{}
+	for _, field := range fields {
+		escaped = append(escaped, markdownEscaper.Replace(field))
+	}
+
+	_, err := io.WriteString(markdown.writer, "| "+strings.Join(escaped, " | ")+" |\n")
+	return err
+}
+
+func (markdown *markdownWriter) Close() error {
+	return nil
+}
+
+type sqlWriter struct {
+	writer    io.Writer
+	tableName string
+	columns   []string
+}
+
+func (writer *sqlWriter) WriteRow(row db.SqliteRow) error {
+	values := []db.SqliteValue{}
+	for _, column := range writer.columns {
+		values = append(values, row[column])
+	}
+
+	_, err := io.WriteString(writer.writer, db.InsertStatement(writer.tableName, writer.columns, values)+"\n")
+	return err
+}
+
+func (writer *sqlWriter) Close() error {
+	return nil
+}
+
+func encodeJSONRow(columns []string, row db.SqliteRow) (string, error) {
+	var builder strings.Builder
+	builder.WriteString("{")
+	for i, column := range columns {
+		if i > 0 {
+			builder.WriteString(",")
+		}
+
+		key, err := json.Marshal(column)
+		if err != nil {
+			return "", err
+		}
+
+		value, err := json.Marshal(jsonValue(row[column]))
+		if err != nil {
+			return "", err
+		}
+
+		builder.Write(key)
+		builder.WriteString(":")
+		builder.Write(value)
+	}
+	builder.WriteString("}")
+
+	return builder.String(), nil
+}
+
+func jsonValue(value db.SqliteValue) any {
+	if blob, ok := value.([]byte); ok {
+		return hex.EncodeToString(blob)
+	}
+
+	return value
+}
+
+func textValue(value db.SqliteValue) string {
+	switch typed := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return hex.EncodeToString(typed)
+	default:
+		return tableformat.FormatCell(typed)
+	}
+}
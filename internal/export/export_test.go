@@ -0,0 +1,114 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"squlito/internal/db"
+)
+
+var exportColumns = []string{"id", "name", "note"}
+
+var exportRows = []db.SqliteRow{
+	{"id": int64(1), "name": "Ava", "note": nil},
+	{"id": int64(2), "name": "Mateo\tR", "note": []byte{0xca, 0xfe}},
+}
+
+func writeExport(t *testing.T, format Format, rows []db.SqliteRow) string {
+	t.Helper()
+
+	var out bytes.Buffer
+	err := WriteRows(&out, format, "people", exportColumns, rows)
+	if err != nil {
+		t.Fatalf("write %s: %v", format, err)
+	}
+
+	return out.String()
+}
+
+func TestWriteRows_CSV(t *testing.T) {
+	got := writeExport(t, FormatCSV, exportRows)
+	want := "id,name,note\n1,Ava,\n2,Mateo\tR,cafe\n"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestWriteRows_TSV(t *testing.T) {
+	got := writeExport(t, FormatTSV, exportRows)
+	want := "id\tname\tnote\n1\tAva\t\n2\tMateo\\tR\tcafe\n"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestWriteRows_JSON(t *testing.T) {
+	got := writeExport(t, FormatJSON, exportRows)
+	want := "[\n  {\"id\":1,\"name\":\"Ava\",\"note\":null},\n  {\"id\":2,\"name\":\"Mateo\\tR\",\"note\":\"cafe\"}\n]\n"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	empty := writeExport(t, FormatJSON, nil)
+	if empty != "[]\n" {
+		t.Fatalf("expected empty array, got %q", empty)
+	}
+}
+
+func TestWriteRows_NDJSON(t *testing.T) {
+	got := writeExport(t, FormatNDJSON, exportRows)
+	lines := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	if len(lines) != 2 || lines[0] != `{"id":1,"name":"Ava","note":null}` {
+		t.Fatalf("expected one object per line, got %q", got)
+	}
+}
+
+func TestWriteRows_Table(t *testing.T) {
+	got := writeExport(t, FormatTable, exportRows)
+	lines := strings.Split(got, "\n")
+	if !strings.HasPrefix(lines[0], "id") || !strings.HasPrefix(lines[1], "---") || !strings.Contains(lines[2], "Ava") {
+		t.Fatalf("expected header, separator and rows, got %q", got)
+	}
+}
+
+func TestWriteRows_Markdown(t *testing.T) {
+	rows := []db.SqliteRow{{"id": int64(1), "name": "a|b", "note": "x\ny"}}
+	got := writeExport(t, FormatMarkdown, rows)
+	want := "| id | name | note |\n| --- | --- | --- |\n| 1 | a\\|b | x<br>y |\n"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestWriteRows_SQL(t *testing.T) {
+	got := writeExport(t, FormatSQL, exportRows[:1])
+	want := "INSERT INTO \"people\" (\"id\", \"name\", \"note\") VALUES (1, 'Ava', NULL);\n"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestFormatFromPath(t *testing.T) {
+	format, ok := FormatFromPath("out/Report.JSONL")
+	if !ok || format != FormatNDJSON {
+		t.Fatalf("expected ndjson, got %q", format)
+	}
+
+	_, ok = FormatFromPath("notes.txt")
+	if ok {
+		t.Fatalf("expected unknown extension")
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat(" NDJSON ")
+	if err != nil || format != FormatNDJSON {
+		t.Fatalf("expected ndjson, got %q (%v)", format, err)
+	}
+
+	_, err = ParseFormat("xml")
+	if err == nil {
+		t.Fatalf("expected unknown format error")
+	}
+}
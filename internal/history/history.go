@@ -0,0 +1,503 @@
+package history
+
+import (
+	"cmp"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TableName     = "query_history"
+	Unlimited     = -1
+	MaxEntriesEnv = "SQULITO_HISTORY_MAX_ENTRIES"
+	MaxAgeEnv     = "SQULITO_HISTORY_MAX_AGE"
+)
+
+// timeLayout is RFC 3339 with a fixed nine-digit fraction, so stored times
+// compare as text in time order. RFC3339Nano trims trailing zeros and sorts
+// "…:05Z" after "…:05.5Z".
+const timeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+type Entry struct {
+	ID        int64
+	SQL       string
+	CreatedAt string
+	LastRunAt string
+	RunCount  int
+	Pinned    bool
+	Bindings  map[string]string
+	DBPath    string
+	Result    Result
+}
+
+type Result struct {
+	Duration  time.Duration
+	RowCount  int
+	Error     string
+	Truncated bool
+}
+
+type Retention struct {
+	MaxEntries int
+	MaxAge     time.Duration
+}
+
+func Open() (*sql.DB, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	dsn := "file:" + url.PathEscape(path) + "?mode=rwc"
+	dbConn, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = dbConn.Exec("PRAGMA foreign_keys = ON")
+	if err != nil {
+		closeErr := dbConn.Close()
+		if closeErr != nil {
+			return nil, closeErr
+		}
+		return nil, err
+	}
+
+	return dbConn, nil
+}
+
+func Path() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(configDir, "squlito", "history.db"), nil
+}
+
+func DatabaseKey(dbPath string) string {
+	if dbPath == "" {
+		return ":memory:"
+	}
+
+	absolute, err := filepath.Abs(dbPath)
+	if err != nil {
+		return dbPath
+	}
+
+	return absolute
+}
+
+func DataFilesKey(paths []string) string {
+	keys := []string{}
+	for _, path := range paths {
+		keys = append(keys, DatabaseKey(path))
+	}
+	slices.Sort(keys)
+
+	return strings.Join(keys, ", ")
+}
+
+func EnsureSchema(dbConn *sql.DB) error {
+	createTable := "CREATE TABLE IF NOT EXISTS " + TableName + " (id INTEGER PRIMARY KEY AUTOINCREMENT, sql TEXT NOT NULL, created_at TEXT NOT NULL)"
+	_, err := dbConn.Exec(createTable)
+	if err != nil {
+		return err
+	}
+
+	createIndex := "CREATE INDEX IF NOT EXISTS query_history_created_at ON " + TableName + " (created_at DESC)"
+	_, err = dbConn.Exec(createIndex)
+	if err != nil {
+		return err
+	}
+
+	columns := []struct {
+		name       string
+		definition string
+	}{
+		{name: "bindings", definition: "TEXT"},
+		{name: "db_path", definition: "TEXT"},
+		{name: "duration_ms", definition: "INTEGER"},
+		{name: "row_count", definition: "INTEGER"},
+		{name: "error", definition: "TEXT"},
+		{name: "truncated", definition: "INTEGER NOT NULL DEFAULT 0"},
+		{name: "last_run_at", definition: "TEXT"},
+		{name: "run_count", definition: "INTEGER NOT NULL DEFAULT 1"},
+		{name: "pinned", definition: "INTEGER NOT NULL DEFAULT 0"},
+	}
+	for _, column := range columns {
+		err = ensureColumn(dbConn, column.name, column.definition)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = dbConn.Exec("UPDATE " + TableName + " SET last_run_at = created_at WHERE last_run_at IS NULL")
+	if err != nil {
+		return err
+	}
+
+	err = normalizeTimes(dbConn)
+	if err != nil {
+		return err
+	}
+
+	var deduplicated int
+	err = dbConn.QueryRow("SELECT count(*) FROM sqlite_master WHERE type = 'index' AND name = 'query_history_sql'").Scan(&deduplicated)
+	if err != nil {
+		return err
+	}
+	if deduplicated == 0 {
+		err = collapseDuplicates(dbConn)
+		if err != nil {
+			return err
+		}
+	}
+
+	indexes := []string{
+		"CREATE INDEX IF NOT EXISTS query_history_db_path ON " + TableName + " (db_path, created_at DESC)",
+		"CREATE INDEX IF NOT EXISTS query_history_last_run_at ON " + TableName + " (db_path, last_run_at DESC)",
+		"CREATE UNIQUE INDEX IF NOT EXISTS query_history_sql ON " + TableName + " (db_path, sql)",
+	}
+	for _, index := range indexes {
+		_, err = dbConn.Exec(index)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ensureColumn(dbConn *sql.DB, name string, definition string) error {
+	var count int
+	err := dbConn.QueryRow("SELECT count(*) FROM pragma_table_info(?) WHERE name = ?", TableName, name).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	_, err = dbConn.Exec("ALTER TABLE " + TableName + " ADD COLUMN " + name + " " + definition)
+	return err
+}
+
+func formatTime(value time.Time) string {
+	return value.UTC().Format(timeLayout)
+}
+
+type storedTimes struct {
+	id        int64
+	createdAt string
+	lastRunAt string
+}
+
+// normalizeTimes rewrites times stored by older versions in timeLayout. Values
+// that do not parse are left as they are.
+func normalizeTimes(dbConn *sql.DB) (err error) {
+	updates, err := loadUnnormalizedTimes(dbConn)
+	if err != nil || len(updates) == 0 {
+		return err
+	}
+
+	tx, err := dbConn.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	for _, stored := range updates {
+		_, err = tx.Exec("UPDATE "+TableName+" SET created_at = ?, last_run_at = ? WHERE id = ?", normalizeTime(stored.createdAt), normalizeTime(stored.lastRunAt), stored.id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func loadUnnormalizedTimes(dbConn *sql.DB) ([]storedTimes, error) {
+	width := len(formatTime(time.Time{}))
+	rows, err := dbConn.Query("SELECT id, created_at, last_run_at FROM "+TableName+" WHERE length(created_at) <> ? OR length(last_run_at) <> ?", width, width)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	times := []storedTimes{}
+	for rows.Next() {
+		var stored storedTimes
+		err = rows.Scan(&stored.id, &stored.createdAt, &stored.lastRunAt)
+		if err != nil {
+			return nil, err
+		}
+		times = append(times, stored)
+	}
+
+	return times, rows.Err()
+}
+
+func normalizeTime(value string) string {
+	parsed, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return value
+	}
+
+	return formatTime(parsed)
+}
+
+func collapseDuplicates(dbConn *sql.DB) (err error) {
+	tx, err := dbConn.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	same := "FROM " + TableName + " AS duplicate WHERE duplicate.sql = " + TableName + ".sql AND duplicate.db_path IS " + TableName + ".db_path"
+	latest := "SELECT id, max(last_run_at) FROM " + TableName + " GROUP BY db_path, sql"
+	_, err = tx.Exec(
+		"UPDATE " + TableName + " SET " +
+			"run_count = (SELECT sum(duplicate.run_count) " + same + "), " +
+			"pinned = (SELECT max(duplicate.pinned) " + same + "), " +
+			"created_at = (SELECT min(duplicate.created_at) " + same + ") " +
+			"WHERE id IN (SELECT id FROM (" + latest + " HAVING count(*) > 1))",
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM " + TableName + " WHERE id NOT IN (SELECT id FROM (" + latest + "))")
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func Load(dbConn *sql.DB, dbPath string, limit int) ([]Entry, error) {
+	if limit == 0 {
+		return []Entry{}, nil
+	}
+
+	query := "SELECT id, sql, created_at, last_run_at, run_count, pinned, bindings, db_path, duration_ms, row_count, error, truncated FROM " + TableName
+	args := []any{}
+	if dbPath != "" {
+		query += " WHERE db_path = ? OR db_path IS NULL"
+		args = append(args, dbPath)
+	}
+	query += " ORDER BY pinned DESC, last_run_at DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := dbConn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	entries := []Entry{}
+	for rows.Next() {
+		var entry Entry
+		var bindings sql.NullString
+		var entryDB sql.NullString
+		var durationMS sql.NullInt64
+		var rowCount sql.NullInt64
+		var errorText sql.NullString
+		err = rows.Scan(&entry.ID, &entry.SQL, &entry.CreatedAt, &entry.LastRunAt, &entry.RunCount, &entry.Pinned, &bindings, &entryDB, &durationMS, &rowCount, &errorText, &entry.Result.Truncated)
+		if err != nil {
+			return nil, err
+		}
+		if bindings.Valid {
+			_ = json.Unmarshal([]byte(bindings.String), &entry.Bindings)
+		}
+		entry.DBPath = entryDB.String
+		entry.Result.Duration = time.Duration(durationMS.Int64) * time.Millisecond
+		entry.Result.RowCount = int(rowCount.Int64)
+		entry.Result.Error = errorText.String
+		entries = append(entries, entry)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func Record(dbConn *sql.DB, dbPath string, sqlText string, bindings map[string]string) (Entry, error) {
+	ranAt := formatTime(time.Now())
+	var encoded any
+	if len(bindings) > 0 {
+		data, err := json.Marshal(bindings)
+		if err != nil {
+			return Entry{}, err
+		}
+		encoded = string(data)
+	}
+
+	entry := Entry{
+		ID:        0,
+		SQL:       sqlText,
+		CreatedAt: "",
+		LastRunAt: ranAt,
+		RunCount:  0,
+		Pinned:    false,
+		Bindings:  bindings,
+		DBPath:    dbPath,
+		Result: Result{
+			Duration:  0,
+			RowCount:  0,
+			Error:     "",
+			Truncated: false,
+		},
+	}
+
+	_, err := dbConn.Exec(
+		"UPDATE "+TableName+" SET db_path = ? WHERE db_path IS NULL AND sql = ? "+
+			"AND NOT EXISTS (SELECT 1 FROM "+TableName+" WHERE db_path = ? AND sql = ?)",
+		dbPath, sqlText, dbPath, sqlText,
+	)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	err = dbConn.QueryRow(
+		"INSERT INTO "+TableName+" (sql, created_at, last_run_at, bindings, db_path) VALUES (?, ?, ?, ?, ?) "+
+			"ON CONFLICT (db_path, sql) DO UPDATE SET last_run_at = excluded.last_run_at, run_count = run_count + 1, "+
+			"bindings = excluded.bindings, duration_ms = NULL, row_count = NULL, error = NULL, truncated = 0 "+
+			"RETURNING id, created_at, run_count, pinned",
+		sqlText, ranAt, ranAt, encoded, dbPath,
+	).Scan(&entry.ID, &entry.CreatedAt, &entry.RunCount, &entry.Pinned)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	return entry, nil
+}
+
+func Sort(entries []Entry) {
+	slices.SortStableFunc(entries, func(left Entry, right Entry) int {
+		if left.Pinned != right.Pinned {
+			if left.Pinned {
+				return -1
+			}
+			return 1
+		}
+		if order := strings.Compare(right.LastRunAt, left.LastRunAt); order != 0 {
+			return order
+		}
+		return cmp.Compare(right.ID, left.ID)
+	})
+}
+
+func UpdateResult(dbConn *sql.DB, id int64, result Result) error {
+	var errorText any
+	if result.Error != "" {
+		errorText = result.Error
+	}
+
+	_, err := dbConn.Exec(
+		"UPDATE "+TableName+" SET duration_ms = ?, row_count = ?, error = ?, truncated = ? WHERE id = ?",
+		result.Duration.Milliseconds(), result.RowCount, errorText, result.Truncated, id,
+	)
+	return err
+}
+
+func SetPinned(dbConn *sql.DB, id int64, pinned bool) error {
+	_, err := dbConn.Exec("UPDATE "+TableName+" SET pinned = ? WHERE id = ?", pinned, id)
+	return err
+}
+
+func Delete(dbConn *sql.DB, id int64) error {
+	_, err := dbConn.Exec("DELETE FROM "+TableName+" WHERE id = ?", id)
+	return err
+}
+
+func RetentionFromEnv() (Retention, error) {
+	retention := Retention{
+		MaxEntries: 0,
+		MaxAge:     0,
+	}
+
+	if value := strings.TrimSpace(os.Getenv(MaxEntriesEnv)); value != "" {
+		count, err := strconv.Atoi(value)
+		if err != nil || count < 0 {
+			return retention, fmt.Errorf("%s=%q is not a non-negative count", MaxEntriesEnv, value)
+		}
+		retention.MaxEntries = count
+	}
+
+	if value := strings.TrimSpace(os.Getenv(MaxAgeEnv)); value != "" {
+		age, err := parseAge(value)
+		if err != nil {
+			return retention, fmt.Errorf("%s=%q: %w", MaxAgeEnv, value, err)
+		}
+		retention.MaxAge = age
+	}
+
+	return retention, nil
+}
+
+func parseAge(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		count, err := strconv.Atoi(days)
+		if err != nil || count < 0 {
+			return 0, fmt.Errorf("expected a number of days like 90d")
+		}
+		return time.Duration(count) * 24 * time.Hour, nil
+	}
+
+	age, err := time.ParseDuration(value)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("expected a number of days like 90d or a duration like 720h")
+	}
+	return age, nil
+}
+
+func Prune(dbConn *sql.DB, retention Retention, now time.Time) error {
+	if retention.MaxAge > 0 {
+		cutoff := formatTime(now.Add(-retention.MaxAge))
+		_, err := dbConn.Exec("DELETE FROM "+TableName+" WHERE pinned = 0 AND last_run_at < ?", cutoff)
+		if err != nil {
+			return err
+		}
+	}
+
+	if retention.MaxEntries > 0 {
+		_, err := dbConn.Exec(
+			"DELETE FROM "+TableName+" WHERE pinned = 0 AND id NOT IN "+
+				"(SELECT id FROM "+TableName+" WHERE pinned = 0 ORDER BY last_run_at DESC, id DESC LIMIT ?)",
+			retention.MaxEntries,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,336 @@
+package history
+
+import (
+	"database/sql"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func openTestStore(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dbConn, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = dbConn.Close()
+	})
+
+	return dbConn
+}
+
+func createLegacyStore(t *testing.T, rows [][2]string) *sql.DB {
+	t.Helper()
+
+	dbConn := openTestStore(t)
+	_, err := dbConn.Exec("CREATE TABLE " + TableName + " (id INTEGER PRIMARY KEY AUTOINCREMENT, sql TEXT NOT NULL, created_at TEXT NOT NULL)")
+	if err != nil {
+		t.Fatalf("legacy schema: %v", err)
+	}
+
+	for _, row := range rows {
+		_, err = dbConn.Exec("INSERT INTO "+TableName+" (sql, created_at) VALUES (?, ?)", row[0], row[1])
+		if err != nil {
+			t.Fatalf("legacy row: %v", err)
+		}
+	}
+
+	return dbConn
+}
+
+func entrySQL(entries []Entry) []string {
+	texts := []string{}
+	for _, entry := range entries {
+		texts = append(texts, entry.SQL)
+	}
+	return texts
+}
+
+func TestLegacyEntriesStayInDatabaseScope(t *testing.T) {
+	dbConn := createLegacyStore(t, [][2]string{
+		{"SELECT 1", "2024-01-01T00:00:00Z"},
+		{"SELECT 2", "2024-01-02T00:00:00Z"},
+	})
+
+	err := EnsureSchema(dbConn)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	_, err = Record(dbConn, "/data/crm.db", "SELECT 3", nil)
+	if err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	entries, err := Load(dbConn, "/data/shop.db", Unlimited)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !slices.Equal(entrySQL(entries), []string{"SELECT 2", "SELECT 1"}) {
+		t.Fatalf("expected legacy entries in every database, got %q", entrySQL(entries))
+	}
+	if entries[0].DBPath != "" || entries[0].LastRunAt != "2024-01-02T00:00:00.000000000Z" || entries[0].RunCount != 1 {
+		t.Fatalf("unexpected migrated entry %+v", entries[0])
+	}
+
+	claimed, err := Record(dbConn, "/data/shop.db", "SELECT 1", nil)
+	if err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if claimed.ID != entries[1].ID || claimed.RunCount != 2 || claimed.CreatedAt != "2024-01-01T00:00:00.000000000Z" {
+		t.Fatalf("expected the legacy entry to be reused, got %+v", claimed)
+	}
+
+	entries, err = Load(dbConn, "/data/crm.db", Unlimited)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !slices.Equal(entrySQL(entries), []string{"SELECT 3", "SELECT 2"}) {
+		t.Fatalf("expected the reused entry to leave other databases, got %q", entrySQL(entries))
+	}
+}
+
+func TestMigrationCollapsesDuplicates(t *testing.T) {
+	dbConn := createLegacyStore(t, [][2]string{
+		{"SELECT 1", "2024-01-01T00:00:00Z"},
+		{"SELECT 2", "2024-01-02T00:00:00Z"},
+		{"SELECT 1", "2024-01-03T00:00:00Z"},
+		{"SELECT 1", "2024-01-04T00:00:00Z"},
+	})
+
+	err := EnsureSchema(dbConn)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	entries, err := Load(dbConn, "", Unlimited)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !slices.Equal(entrySQL(entries), []string{"SELECT 1", "SELECT 2"}) {
+		t.Fatalf("expected duplicates to collapse, got %q", entrySQL(entries))
+	}
+
+	collapsed := entries[0]
+	if collapsed.RunCount != 3 || collapsed.CreatedAt != "2024-01-01T00:00:00.000000000Z" || collapsed.LastRunAt != "2024-01-04T00:00:00.000000000Z" {
+		t.Fatalf("unexpected collapsed entry %+v", collapsed)
+	}
+
+	err = EnsureSchema(dbConn)
+	if err != nil {
+		t.Fatalf("second migration: %v", err)
+	}
+
+	again, err := Load(dbConn, "", Unlimited)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(again) != len(entries) {
+		t.Fatalf("expected the second migration to keep %d entries, got %d", len(entries), len(again))
+	}
+	for index := range entries {
+		if again[index].ID != entries[index].ID || again[index].RunCount != entries[index].RunCount || again[index].LastRunAt != entries[index].LastRunAt {
+			t.Fatalf("second migration changed %+v to %+v", entries[index], again[index])
+		}
+	}
+}
+
+func TestMigrationOrdersSubsecondTimes(t *testing.T) {
+	dbConn := createLegacyStore(t, [][2]string{
+		{"SELECT 'half past'", "2024-01-01T00:00:05.5Z"},
+		{"SELECT 'on the second'", "2024-01-01T00:00:05Z"},
+		{"SELECT 'later'", "2024-01-01T00:00:05.75Z"},
+	})
+
+	err := EnsureSchema(dbConn)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	entries, err := Load(dbConn, "", Unlimited)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	expected := []string{"SELECT 'later'", "SELECT 'half past'", "SELECT 'on the second'"}
+	if !slices.Equal(entrySQL(entries), expected) {
+		t.Fatalf("expected newest first, got %q", entrySQL(entries))
+	}
+	if entries[2].LastRunAt != "2024-01-01T00:00:05.000000000Z" || entries[2].CreatedAt != entries[2].LastRunAt {
+		t.Fatalf("expected fixed-width times, got %+v", entries[2])
+	}
+
+	err = Prune(dbConn, Retention{MaxEntries: 2, MaxAge: 0}, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	entries, err = Load(dbConn, "", Unlimited)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !slices.Equal(entrySQL(entries), expected[:2]) {
+		t.Fatalf("expected prune to drop the oldest entry, got %q", entrySQL(entries))
+	}
+}
+
+func TestRecordUpdatesRepeatedQueries(t *testing.T) {
+	dbConn := openTestStore(t)
+	err := EnsureSchema(dbConn)
+	if err != nil {
+		t.Fatalf("schema: %v", err)
+	}
+
+	first, err := Record(dbConn, "/data/shop.db", "SELECT * FROM t WHERE id = ?", map[string]string{"?1": "1"})
+	if err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	err = UpdateResult(dbConn, first.ID, Result{Duration: time.Second, RowCount: 5, Error: "", Truncated: true})
+	if err != nil {
+		t.Fatalf("update result: %v", err)
+	}
+
+	second, err := Record(dbConn, "/data/shop.db", "SELECT * FROM t WHERE id = ?", map[string]string{"?1": "2"})
+	if err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if second.ID != first.ID || second.RunCount != 2 || second.CreatedAt != first.CreatedAt {
+		t.Fatalf("expected the first entry to be reused, got %+v after %+v", second, first)
+	}
+
+	other, err := Record(dbConn, "/data/crm.db", "SELECT * FROM t WHERE id = ?", nil)
+	if err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if other.ID == first.ID || other.RunCount != 1 {
+		t.Fatalf("expected a separate entry for another database, got %+v", other)
+	}
+
+	entries, err := Load(dbConn, "/data/shop.db", Unlimited)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected one entry, got %+v", entries)
+	}
+	entry := entries[0]
+	if entry.RunCount != 2 || entry.Bindings["?1"] != "2" || entry.Result.RowCount != 0 || entry.Result.Truncated {
+		t.Fatalf("expected the latest run's bindings and a cleared result, got %+v", entry)
+	}
+}
+
+func TestPinnedEntriesAreListedFirstAndKept(t *testing.T) {
+	dbConn := openTestStore(t)
+	err := EnsureSchema(dbConn)
+	if err != nil {
+		t.Fatalf("schema: %v", err)
+	}
+
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	runs := []struct {
+		sql  string
+		age  time.Duration
+		pins bool
+	}{
+		{sql: "SELECT 'old pinned'", age: 400 * 24 * time.Hour, pins: true},
+		{sql: "SELECT 'old'", age: 200 * 24 * time.Hour, pins: false},
+		{sql: "SELECT 'recent'", age: 2 * 24 * time.Hour, pins: false},
+		{sql: "SELECT 'newest'", age: time.Hour, pins: false},
+	}
+	for _, run := range runs {
+		entry, err := Record(dbConn, "/data/shop.db", run.sql, nil)
+		if err != nil {
+			t.Fatalf("record: %v", err)
+		}
+		_, err = dbConn.Exec("UPDATE "+TableName+" SET last_run_at = ? WHERE id = ?", formatTime(now.Add(-run.age)), entry.ID)
+		if err != nil {
+			t.Fatalf("backdate: %v", err)
+		}
+		err = SetPinned(dbConn, entry.ID, run.pins)
+		if err != nil {
+			t.Fatalf("pin: %v", err)
+		}
+	}
+
+	entries, err := Load(dbConn, "/data/shop.db", Unlimited)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	expected := []string{"SELECT 'old pinned'", "SELECT 'newest'", "SELECT 'recent'", "SELECT 'old'"}
+	if !slices.Equal(entrySQL(entries), expected) {
+		t.Fatalf("expected pinned entries first, got %q", entrySQL(entries))
+	}
+
+	slices.Reverse(entries)
+	Sort(entries)
+	if !slices.Equal(entrySQL(entries), expected) {
+		t.Fatalf("Sort disagrees with Load: %q", entrySQL(entries))
+	}
+
+	err = Prune(dbConn, Retention{MaxEntries: 0, MaxAge: 0}, now)
+	if err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	entries, _ = Load(dbConn, "", Unlimited)
+	if len(entries) != 4 {
+		t.Fatalf("expected no pruning without a policy, got %q", entrySQL(entries))
+	}
+
+	err = Prune(dbConn, Retention{MaxEntries: 0, MaxAge: 90 * 24 * time.Hour}, now)
+	if err != nil {
+		t.Fatalf("prune by age: %v", err)
+	}
+	entries, _ = Load(dbConn, "", Unlimited)
+	if !slices.Equal(entrySQL(entries), []string{"SELECT 'old pinned'", "SELECT 'newest'", "SELECT 'recent'"}) {
+		t.Fatalf("unexpected entries after pruning by age: %q", entrySQL(entries))
+	}
+
+	err = Prune(dbConn, Retention{MaxEntries: 1, MaxAge: 0}, now)
+	if err != nil {
+		t.Fatalf("prune by count: %v", err)
+	}
+	entries, _ = Load(dbConn, "", Unlimited)
+	if !slices.Equal(entrySQL(entries), []string{"SELECT 'old pinned'", "SELECT 'newest'"}) {
+		t.Fatalf("unexpected entries after pruning by count: %q", entrySQL(entries))
+	}
+}
+
+func TestRetentionFromEnv(t *testing.T) {
+	t.Setenv(MaxEntriesEnv, "")
+	t.Setenv(MaxAgeEnv, "")
+	retention, err := RetentionFromEnv()
+	if err != nil || retention.MaxEntries != 0 || retention.MaxAge != 0 {
+		t.Fatalf("expected no retention by default, got %+v, %v", retention, err)
+	}
+
+	t.Setenv(MaxEntriesEnv, "500")
+	t.Setenv(MaxAgeEnv, "90d")
+	retention, err = RetentionFromEnv()
+	if err != nil || retention.MaxEntries != 500 || retention.MaxAge != 90*24*time.Hour {
+		t.Fatalf("unexpected retention %+v, %v", retention, err)
+	}
+
+	t.Setenv(MaxAgeEnv, "720h")
+	retention, err = RetentionFromEnv()
+	if err != nil || retention.MaxAge != 30*24*time.Hour {
+		t.Fatalf("unexpected retention %+v, %v", retention, err)
+	}
+
+	for _, value := range []string{"soon", "-1d"} {
+		t.Setenv(MaxAgeEnv, value)
+		_, err = RetentionFromEnv()
+		if err == nil {
+			t.Fatalf("expected an error for %s=%q", MaxAgeEnv, value)
+		}
+	}
+}
+
+func TestDataFilesKey(t *testing.T) {
+	key := DataFilesKey([]string{"/data/b.csv", "/data/a.json"})
+	if key != "/data/a.json, /data/b.csv" {
+		t.Fatalf("unexpected key %q", key)
+	}
+}
@@ -0,0 +1,598 @@
+package importer
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"squlito/internal/db"
+)
+
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatTSV    Format = "tsv"
+	FormatJSON   Format = "json"
+	FormatNDJSON Format = "ndjson"
+)
+
+const (
+	sampleSize = 1000
+	sniffSize  = 4096
+)
+
+const sqliteHeader = "SQLite format 3\x00"
+
+var extensionFormats = map[string]Format{
+	".csv":    FormatCSV,
+	".tsv":    FormatTSV,
+	".json":   FormatJSON,
+	".ndjson": FormatNDJSON,
+	".jsonl":  FormatNDJSON,
+}
+
+var tableNameCleaner = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+type Progress struct {
+	Rows       int
+	BytesRead  int64
+	TotalBytes int64
+}
+
+type Options struct {
+	Path     string
+	Table    string
+	Format   Format
+	Progress func(progress Progress)
+}
+
+type Result struct {
+	Table   string
+	Columns []db.ColumnDefinition
+	Rows    int
+}
+
+type rowReader interface {
+	next() ([]db.SqliteValue, error)
+}
+
+func FormatFromPath(path string) (Format, bool) {
+	format, ok := extensionFormats[strings.ToLower(filepath.Ext(path))]
+	return format, ok
+}
+
+func DetectFormat(path string) (Format, bool) {
+	format, ok := FormatFromPath(path)
+	if ok {
+		return format, true
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", false
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	buffer := make([]byte, sniffSize)
+	read, err := io.ReadFull(file, buffer)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", false
+	}
+
+	return sniffFormat(string(buffer[:read]))
+}
+
+func sniffFormat(content string) (Format, bool) {
+	if strings.HasPrefix(content, sqliteHeader) || strings.ContainsRune(content, 0) {
+		return "", false
+	}
+
+	trimmed := strings.TrimLeft(strings.TrimPrefix(content, "\ufeff"), " \t\r\n")
+	switch {
+	case strings.HasPrefix(trimmed, "["):
+		return FormatJSON, true
+	case strings.HasPrefix(trimmed, "{"):
+		return FormatNDJSON, true
+	}
+
+	firstLine, _, _ := strings.Cut(trimmed, "\n")
+	switch {
+	case strings.Contains(firstLine, "\t"):
+		return FormatTSV, true
+	case strings.Contains(firstLine, ","):
+		return FormatCSV, true
+	default:
+		return "", false
+	}
+}
+
+func ImportFiles(ctx context.Context, conn *sql.DB, paths []string, progress func(path string, progress Progress)) ([]Result, error) {
+	results := []Result{}
+	used := map[string]int{}
+	for _, path := range paths {
+		format, ok := DetectFormat(path)
+		if !ok {
+			return results, fmt.Errorf("cannot tell the format of %s: use a .csv, .tsv, .json or .ndjson file", path)
+		}
+
+		table := TableNameFromPath(path)
+		key := strings.ToLower(table)
+		used[key] += 1
+		if used[key] > 1 {
+			table = fmt.Sprintf("%s_%d", table, used[key])
+		}
+
+		result, err := ImportFile(ctx, conn, Options{
+			Path:   path,
+			Table:  table,
+			Format: format,
+			Progress: func(fileProgress Progress) {
+				if progress != nil {
+					progress(path, fileProgress)
+				}
+			},
+		})
+		if err != nil {
+			return results, err
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func ParseFormat(value string) (Format, error) {
+	normalized := Format(strings.ToLower(strings.TrimSpace(value)))
+	switch normalized {
+	case FormatCSV, FormatTSV, FormatJSON, FormatNDJSON:
+		return normalized, nil
+	default:
+		return "", fmt.Errorf("unknown import format %q (expected csv, tsv, json or ndjson)", value)
+	}
+}
+
+func TableNameFromPath(path string) string {
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	name := strings.Trim(tableNameCleaner.ReplaceAllString(base, "_"), "_")
+	if name == "" {
+		return "imported"
+	}
+
+	if name[0] >= '0' && name[0] <= '9' {
+		name = "t_" + name
+	}
+
+	return name
+}
+
+func ImportFile(ctx context.Context, conn *sql.DB, options Options) (result Result, err error) {
+	format := options.Format
+	if format == "" {
+		detected, ok := FormatFromPath(options.Path)
+		if !ok {
+			return result, fmt.Errorf("cannot tell the format of %s: use a .csv, .tsv, .json or .ndjson file", options.Path)
+		}
+		format = detected
+	}
+
+	tableName := options.Table
+	if tableName == "" {
+		tableName = TableNameFromPath(options.Path)
+	}
+
+	file, err := os.Open(options.Path)
+	if err != nil {
+		return result, err
+	}
+	defer func() {
+		closeErr := file.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	info, err := file.Stat()
+	if err != nil {
+		return result, err
+	}
+
+	counter := &countingReader{reader: file, count: 0}
+	names, sample, reader, err := openRows(bufio.NewReader(counter), format)
+	if err != nil {
+		return result, fmt.Errorf("read %s: %w", options.Path, err)
+	}
+
+	columns := inferColumns(names, sample)
+	next := func() ([]db.SqliteValue, error) {
+		if len(sample) > 0 {
+			row := sample[0]
+			sample = sample[1:]
+			return row, nil
+		}
+
+		return reader.next()
+	}
+
+	progress := func(rows int) {
+		if options.Progress != nil {
+			options.Progress(Progress{Rows: rows, BytesRead: counter.count, TotalBytes: info.Size()})
+		}
+	}
+
+	count, err := db.ImportRows(ctx, conn, tableName, columns, next, progress)
+	if err != nil {
+		return result, err
+	}
+
+	return Result{Table: tableName, Columns: columns, Rows: count}, nil
+}
+
+func openRows(input io.Reader, format Format) (columns []string, sample [][]db.SqliteValue, reader rowReader, err error) {
+	switch format {
+	case FormatCSV, FormatTSV:
+		delimited := newDelimitedReader(bufio.NewReader(input), format)
+		columns, err = delimited.header()
+		reader = delimited
+	case FormatJSON, FormatNDJSON:
+		var objects *objectReader
+		objects, err = newObjectReader(input, format)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		columns, sample, err = objects.sample(sampleSize)
+		return columns, sample, objects, err
+	default:
+		return nil, nil, nil, fmt.Errorf("unknown import format %q", format)
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	for len(sample) < sampleSize {
+		row, nextErr := reader.next()
+		if errors.Is(nextErr, io.EOF) {
+			break
+		}
+		if nextErr != nil {
+			return nil, nil, nil, nextErr
+		}
+		sample = append(sample, row)
+	}
+
+	return columns, sample, reader, nil
+}
+
+func inferColumns(names []string, sample [][]db.SqliteValue) []db.ColumnDefinition {
+	columns := []db.ColumnDefinition{}
+	for index, name := range names {
+		values := []db.SqliteValue{}
+		for _, row := range sample {
+			if index < len(row) {
+				values = append(values, row[index])
+			}
+		}
+
+		columns = append(columns, db.ColumnDefinition{Name: name, Type: inferType(values)})
+	}
+
+	return columns
+}
+
+func inferType(values []db.SqliteValue) string {
+	inferred := ""
+	for _, value := range values {
+		var kind string
+		switch typed := value.(type) {
+		case nil:
+			continue
+		case int64:
+			kind = "INTEGER"
+		case string:
+			kind = textKind(typed)
+		default:
+			kind = "TEXT"
+		}
+
+		switch {
+		case kind == "TEXT":
+			return "TEXT"
+		case inferred == "" || inferred == kind:
+			inferred = kind
+		default:
+			inferred = "REAL"
+		}
+	}
+
+	if inferred == "" {
+		return "TEXT"
+	}
+
+	return inferred
+}
+
+func textKind(value string) string {
+	trimmed := strings.TrimPrefix(strings.TrimPrefix(value, "-"), "+")
+	if len(trimmed) > 1 && trimmed[0] == '0' && trimmed[1] != '.' {
+		return "TEXT"
+	}
+
+	_, err := strconv.ParseInt(value, 10, 64)
+	if err == nil {
+		return "INTEGER"
+	}
+
+	_, err = strconv.ParseFloat(value, 64)
+	if err == nil && !strings.ContainsAny(value, "xXpP_") && !strings.EqualFold(trimmed, "inf") && !strings.EqualFold(trimmed, "infinity") && !strings.EqualFold(trimmed, "nan") {
+		return "REAL"
+	}
+
+	return "TEXT"
+}
+
+func columnNames(raw []string) []string {
+	names := []string{}
+	seen := map[string]int{}
+	for index, name := range raw {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			name = fmt.Sprintf("column_%d", index+1)
+		}
+
+		key := strings.ToLower(name)
+		seen[key] += 1
+		if seen[key] > 1 {
+			name = fmt.Sprintf("%s_%d", name, seen[key])
+		}
+
+		names = append(names, name)
+	}
+
+	return names
+}
+
+type delimitedReader struct {
+	read    func() ([]string, error)
+	columns int
+	line    int
+}
+
+var tsvUnescaper = strings.NewReplacer("\\\\", "\\", "\\t", "\t", "\\n", "\n", "\\r", "\r")
+
+func newDelimitedReader(input *bufio.Reader, format Format) *delimitedReader {
+	if format == FormatTSV {
+		return &delimitedReader{read: func() ([]string, error) { return readTSVLine(input) }, columns: 0, line: 0}
+	}
+
+	reader := csv.NewReader(input)
+	reader.FieldsPerRecord = -1
+	return &delimitedReader{read: reader.Read, columns: 0, line: 0}
+}
+
+func readTSVLine(input *bufio.Reader) ([]string, error) {
+	line := ""
+	for line == "" {
+		raw, err := input.ReadString('\n')
+		if err != nil && (!errors.Is(err, io.EOF) || raw == "") {
+			return nil, err
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(raw, "\n"), "\r")
+	}
+
+	fields := strings.Split(line, "\t")
+	for i, field := range fields {
+		fields[i] = tsvUnescaper.Replace(field)
+	}
+
+	return fields, nil
+}
+
+func (delimited *delimitedReader) header() ([]string, error) {
+	record, err := delimited.read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	record[0] = strings.TrimPrefix(record[0], "\ufeff")
+	delimited.columns = len(record)
+	delimited.line = 1
+	return columnNames(record), nil
+}
+
+func (delimited *delimitedReader) next() ([]db.SqliteValue, error) {
+	record, err := delimited.read()
+	if err != nil {
+		return nil, err
+	}
+
+	delimited.line += 1
+	if len(record) > delimited.columns {
+		return nil, fmt.Errorf("record %d has %d fields, expected %d", delimited.line, len(record), delimited.columns)
+	}
+
+	values := make([]db.SqliteValue, len(record))
+	for i, field := range record {
+		if field != "" {
+			values[i] = field
+		}
+	}
+
+	return values, nil
+}
+
+type objectReader struct {
+	decoder *json.Decoder
+	keys    []string
+	index   map[string]int
+	frozen  bool
+	count   int
+}
+
+func newObjectReader(input io.Reader, format Format) (*objectReader, error) {
+	decoder := json.NewDecoder(input)
+	decoder.UseNumber()
+
+	if format == FormatJSON {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("file is empty")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if token != json.Delim('[') {
+			return nil, fmt.Errorf("expected a JSON array of objects")
+		}
+	}
+
+	return &objectReader{decoder: decoder, keys: nil, index: map[string]int{}, frozen: false, count: 0}, nil
+}
+
+func (objects *objectReader) sample(limit int) ([]string, [][]db.SqliteValue, error) {
+	records := []map[string]db.SqliteValue{}
+	for len(records) < limit {
+		record, err := objects.readObject()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		records = append(records, record)
+	}
+
+	objects.frozen = true
+	if len(objects.keys) == 0 {
+		return nil, nil, fmt.Errorf("no objects with fields found")
+	}
+
+	rows := [][]db.SqliteValue{}
+	for _, record := range records {
+		rows = append(rows, objects.align(record))
+	}
+
+	return columnNames(objects.keys), rows, nil
+}
+
+func (objects *objectReader) next() ([]db.SqliteValue, error) {
+	record, err := objects.readObject()
+	if err != nil {
+		return nil, err
+	}
+
+	for key := range record {
+		if _, ok := objects.index[key]; !ok {
+			return nil, fmt.Errorf("object %d has field %q, which is not in the first %d objects", objects.count, key, sampleSize)
+		}
+	}
+
+	return objects.align(record), nil
+}
+
+func (objects *objectReader) align(record map[string]db.SqliteValue) []db.SqliteValue {
+	values := make([]db.SqliteValue, len(objects.keys))
+	for key, value := range record {
+		values[objects.index[key]] = value
+	}
+
+	return values
+}
+
+func (objects *objectReader) readObject() (map[string]db.SqliteValue, error) {
+	if !objects.decoder.More() {
+		return nil, io.EOF
+	}
+
+	token, err := objects.decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	objects.count += 1
+	if token != json.Delim('{') {
+		return nil, fmt.Errorf("item %d is not an object", objects.count)
+	}
+
+	record := map[string]db.SqliteValue{}
+	for objects.decoder.More() {
+		token, err = objects.decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		key, ok := token.(string)
+		if !ok {
+			return nil, fmt.Errorf("item %d has an invalid key", objects.count)
+		}
+
+		var value any
+		err = objects.decoder.Decode(&value)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, known := objects.index[key]; !known && !objects.frozen {
+			objects.index[key] = len(objects.keys)
+			objects.keys = append(objects.keys, key)
+		}
+		record[key] = jsonValue(value)
+	}
+
+	_, err = objects.decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+func jsonValue(value any) db.SqliteValue {
+	switch typed := value.(type) {
+	case nil:
+		return nil
+	case json.Number:
+		return typed.String()
+	case string:
+		return typed
+	case bool:
+		if typed {
+			return int64(1)
+		}
+		return int64(0)
+	default:
+		encoded, err := json.Marshal(typed)
+		if err != nil {
+			return fmt.Sprint(typed)
+		}
+		return string(encoded)
+	}
+}
+
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (counter *countingReader) Read(buffer []byte) (int, error) {
+	read, err := counter.reader.Read(buffer)
+	counter.count += int64(read)
+	return read, err
+}
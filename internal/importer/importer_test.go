@@ -0,0 +1,265 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"squlito/internal/db"
+)
+
+func createImportDb(t *testing.T) *sql.DB {
+	t.Helper()
+
+	conn, err := db.OpenOrCreateDatabase(filepath.Join(t.TempDir(), "import.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() {
+		err := conn.Close()
+		if err != nil {
+			t.Fatalf("close db: %v", err)
+		}
+	})
+
+	return conn
+}
+
+func writeImportFile(t *testing.T, name string, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(path, []byte(content), 0o600)
+	if err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+
+	return path
+}
+
+func columnTypes(columns []db.ColumnDefinition) map[string]string {
+	types := map[string]string{}
+	for _, column := range columns {
+		types[column.Name] = column.Type
+	}
+	return types
+}
+
+func TestImportFile_CSV(t *testing.T) {
+	conn := createImportDb(t)
+	path := writeImportFile(t, "2024 sales-report.csv", "\ufeffid,name,amount,zip,name\n1,Ava,2.5,02134,x\n2,\"Mateo, Jr\",3,10001,\n3,,,,\n")
+
+	progressCalls := 0
+	result, err := ImportFile(context.Background(), conn, Options{
+		Path:     path,
+		Table:    "",
+		Format:   "",
+		Progress: func(progress Progress) { progressCalls += 1 },
+	})
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+
+	if result.Table != "t_2024_sales_report" || result.Rows != 3 || progressCalls == 0 {
+		t.Fatalf("unexpected result %+v (progress calls %d)", result, progressCalls)
+	}
+
+	types := columnTypes(result.Columns)
+	if types["id"] != "INTEGER" || types["amount"] != "REAL" || types["zip"] != "TEXT" || types["name"] != "TEXT" || types["name_2"] != "TEXT" {
+		t.Fatalf("unexpected column types %v", types)
+	}
+
+	rows, err := db.QueryRows(conn, `SELECT * FROM "t_2024_sales_report" ORDER BY id`, 0)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+
+	if rows.Rows[1]["name"] != "Mateo, Jr" || rows.Rows[1]["amount"] != 3.0 || rows.Rows[0]["zip"] != "02134" || rows.Rows[2]["name"] != nil {
+		t.Fatalf("unexpected rows %v", rows.Rows)
+	}
+}
+
+func TestImportFile_WaitsForOpenQueryStream(t *testing.T) {
+	conn := createImportDb(t)
+	_, err := conn.Exec("CREATE TABLE items AS WITH RECURSIVE c(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM c WHERE n < 100) SELECT n FROM c")
+	if err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	cursor, err := db.OpenQueryCursor(context.Background(), conn, "SELECT n FROM items")
+	if err != nil {
+		t.Fatalf("open cursor: %v", err)
+	}
+	_, err = cursor.Fetch(10)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+
+	closed := make(chan error, 1)
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		closed <- cursor.Close()
+	}()
+
+	path := writeImportFile(t, "people.csv", "id,name\n1,Ava\n2,Mateo\n")
+	result, err := ImportFile(context.Background(), conn, Options{Path: path, Table: "", Format: "", Progress: nil})
+	if err != nil {
+		t.Fatalf("import while a query stream was open: %v", err)
+	}
+	if result.Rows != 2 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	err = <-closed
+	if err != nil {
+		t.Fatalf("close cursor: %v", err)
+	}
+}
+
+func TestImportFile_TSV(t *testing.T) {
+	conn := createImportDb(t)
+	path := writeImportFile(t, "notes.tsv", "id\tnote\n1\tline\\none\n\n2\ttab\\there \"quoted\"\n")
+
+	result, err := ImportFile(context.Background(), conn, Options{Path: path, Table: "notes", Format: "", Progress: nil})
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+
+	rows, err := db.QueryRows(conn, "SELECT note FROM notes ORDER BY id", 0)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+
+	if result.Rows != 2 || rows.Rows[0]["note"] != "line\none" || rows.Rows[1]["note"] != "tab\there \"quoted\"" {
+		t.Fatalf("unexpected rows %v", rows.Rows)
+	}
+}
+
+func TestImportFile_JSON(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		content string
+	}{
+		{name: "people.json", content: `[{"id": 1, "name": "Ava", "tags": ["a"]}, {"id": 2, "active": true, "name": null}]`},
+		{name: "people.ndjson", content: "{\"id\": 1, \"name\": \"Ava\", \"tags\": [\"a\"]}\n\n{\"id\": 2, \"active\": true, \"name\": null}\n"},
+	} {
+		conn := createImportDb(t)
+		path := writeImportFile(t, test.name, test.content)
+
+		result, err := ImportFile(context.Background(), conn, Options{Path: path, Table: "", Format: "", Progress: nil})
+		if err != nil {
+			t.Fatalf("%s: import: %v", test.name, err)
+		}
+
+		types := columnTypes(result.Columns)
+		if result.Table != "people" || types["id"] != "INTEGER" || types["active"] != "INTEGER" || types["tags"] != "TEXT" {
+			t.Fatalf("%s: unexpected result %+v", test.name, result)
+		}
+
+		rows, err := db.QueryRows(conn, "SELECT * FROM people ORDER BY id", 0)
+		if err != nil {
+			t.Fatalf("%s: query: %v", test.name, err)
+		}
+
+		if rows.Rows[0]["tags"] != `["a"]` || rows.Rows[0]["active"] != nil || rows.Rows[1]["active"] != int64(1) || rows.Rows[1]["name"] != nil {
+			t.Fatalf("%s: unexpected rows %v", test.name, rows.Rows)
+		}
+	}
+}
+
+func TestImportFile_ExistingTable(t *testing.T) {
+	conn := createImportDb(t)
+	_, err := conn.Exec("CREATE TABLE people (id INTEGER)")
+	if err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+
+	path := writeImportFile(t, "people.csv", "id\n1\n")
+	_, err = ImportFile(context.Background(), conn, Options{Path: path, Table: "", Format: "", Progress: nil})
+	if err == nil {
+		t.Fatalf("expected an error importing into an existing table")
+	}
+}
+
+func TestInferType(t *testing.T) {
+	tests := []struct {
+		values []db.SqliteValue
+		want   string
+	}{
+		{values: []db.SqliteValue{"1", "-2", nil}, want: "INTEGER"},
+		{values: []db.SqliteValue{"1", "2.5"}, want: "REAL"},
+		{values: []db.SqliteValue{"1e3", "0.5"}, want: "REAL"},
+		{values: []db.SqliteValue{"007"}, want: "TEXT"},
+		{values: []db.SqliteValue{"1", "NaN"}, want: "TEXT"},
+		{values: []db.SqliteValue{nil}, want: "TEXT"},
+	}
+
+	for _, test := range tests {
+		got := inferType(test.values)
+		if got != test.want {
+			t.Fatalf("inferType(%v) = %s, want %s", test.values, got, test.want)
+		}
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    Format
+		ok      bool
+	}{
+		{name: "export.txt", content: "\n  [{\"id\": 1}]", want: FormatJSON, ok: true},
+		{name: "events.log", content: "{\"id\": 1}\n{\"id\": 2}\n", want: FormatNDJSON, ok: true},
+		{name: "dump", content: "id\tname\n1\tAva\n", want: FormatTSV, ok: true},
+		{name: "dump2", content: "id,name\n1,Ava\n", want: FormatCSV, ok: true},
+		{name: "notes.csv", content: "just text", want: FormatCSV, ok: true},
+		{name: "app.db", content: "SQLite format 3\x00rest", want: "", ok: false},
+		{name: "readme", content: "plain words", want: "", ok: false},
+	}
+
+	for _, test := range tests {
+		path := writeImportFile(t, test.name, test.content)
+		got, ok := DetectFormat(path)
+		if got != test.want || ok != test.ok {
+			t.Fatalf("DetectFormat(%s) = %q, %v; want %q, %v", test.name, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestImportFiles(t *testing.T) {
+	conn, err := db.OpenMemoryDatabase(t.Name())
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			t.Fatalf("close db: %v", err)
+		}
+	}()
+
+	paths := []string{
+		writeImportFile(t, "data.csv", "id\n1\n2\n"),
+		writeImportFile(t, "data.ndjson", "{\"id\": 3}\n"),
+	}
+
+	results, err := ImportFiles(context.Background(), conn, paths, nil)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+
+	if len(results) != 2 || results[0].Table != "data" || results[1].Table != "data_2" || results[0].Rows != 2 || results[1].Rows != 1 {
+		t.Fatalf("unexpected results %+v", results)
+	}
+
+	tables, err := db.ListUserTables(conn)
+	if err != nil || len(tables) != 2 {
+		t.Fatalf("expected both tables in the shared memory database, got %v (%v)", tables, err)
+	}
+}
@@ -0,0 +1,240 @@
+package snippets
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+const TableName = "snippets"
+
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatTOML Format = "toml"
+)
+
+type Snippet struct {
+	Name     string   `json:"name" toml:"name"`
+	SQL      string   `json:"sql" toml:"sql"`
+	Tags     []string `json:"tags,omitempty" toml:"tags,omitempty"`
+	Database string   `json:"database,omitempty" toml:"database,omitempty"`
+}
+
+type library struct {
+	Snippets []Snippet `json:"snippets" toml:"snippet"`
+}
+
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+func EnsureSchema(dbConn *sql.DB) error {
+	createTable := "CREATE TABLE IF NOT EXISTS " + TableName + " (" +
+		"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+		"name TEXT NOT NULL, " +
+		"sql TEXT NOT NULL, " +
+		"tags TEXT NOT NULL DEFAULT '', " +
+		"db_path TEXT NOT NULL DEFAULT '', " +
+		"updated_at TEXT NOT NULL, " +
+		"UNIQUE (name, db_path))"
+	_, err := dbConn.Exec(createTable)
+	return err
+}
+
+func Save(dbConn *sql.DB, snippet Snippet) error {
+	return save(dbConn, snippet)
+}
+
+func SaveAll(dbConn *sql.DB, snippets []Snippet) (err error) {
+	tx, err := dbConn.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	for _, snippet := range snippets {
+		err = save(tx, snippet)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func save(dbConn execer, snippet Snippet) error {
+	name := strings.TrimSpace(snippet.Name)
+	if name == "" {
+		return fmt.Errorf("snippet name is empty")
+	}
+	if strings.TrimSpace(snippet.SQL) == "" {
+		return fmt.Errorf("snippet %q has no SQL", name)
+	}
+
+	updatedAt := time.Now().UTC().Format(time.RFC3339Nano)
+	_, err := dbConn.Exec(
+		"INSERT INTO "+TableName+" (name, sql, tags, db_path, updated_at) VALUES (?, ?, ?, ?, ?) "+
+			"ON CONFLICT (name, db_path) DO UPDATE SET sql = excluded.sql, tags = excluded.tags, updated_at = excluded.updated_at",
+		name, snippet.SQL, strings.Join(NormalizeTags(snippet.Tags), ","), snippet.Database, updatedAt,
+	)
+	return err
+}
+
+func Delete(dbConn *sql.DB, snippet Snippet) error {
+	_, err := dbConn.Exec("DELETE FROM "+TableName+" WHERE name = ? AND db_path = ?", snippet.Name, snippet.Database)
+	return err
+}
+
+func List(dbConn *sql.DB, database string) ([]Snippet, error) {
+	query := "SELECT name, sql, tags, db_path FROM " + TableName
+	args := []any{}
+	if database != "" {
+		query += " WHERE db_path = '' OR db_path = ?"
+		args = append(args, database)
+	}
+	query += " ORDER BY name COLLATE NOCASE, db_path"
+
+	rows, err := dbConn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	snippets := []Snippet{}
+	for rows.Next() {
+		var snippet Snippet
+		var tags string
+		err = rows.Scan(&snippet.Name, &snippet.SQL, &tags, &snippet.Database)
+		if err != nil {
+			return nil, err
+		}
+		snippet.Tags = ParseTags(tags)
+		snippets = append(snippets, snippet)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return snippets, nil
+}
+
+func PortableDatabase(database string, baseDir string) string {
+	return mapDatabasePaths(database, func(path string) string {
+		if !filepath.IsAbs(path) {
+			return path
+		}
+
+		relative, err := filepath.Rel(baseDir, path)
+		if err != nil {
+			return path
+		}
+		return filepath.ToSlash(relative)
+	})
+}
+
+func ResolveDatabase(database string, baseDir string) string {
+	return mapDatabasePaths(database, func(path string) string {
+		path = filepath.FromSlash(path)
+		if path == ":memory:" || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(baseDir, path)
+	})
+}
+
+func mapDatabasePaths(database string, convert func(string) string) string {
+	if database == "" {
+		return ""
+	}
+
+	paths := []string{}
+	for path := range strings.SplitSeq(database, ", ") {
+		paths = append(paths, convert(path))
+	}
+	slices.Sort(paths)
+	return strings.Join(paths, ", ")
+}
+
+func ParseTags(value string) []string {
+	return NormalizeTags(strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' '
+	}))
+}
+
+func NormalizeTags(tags []string) []string {
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
+
+func FormatFromPath(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON, nil
+	case ".toml":
+		return FormatTOML, nil
+	default:
+		return "", fmt.Errorf("unsupported snippet file %q (expected .toml or .json)", path)
+	}
+}
+
+func Write(writer io.Writer, format Format, snippets []Snippet) error {
+	file := library{Snippets: snippets}
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(file)
+	case FormatTOML:
+		return toml.NewEncoder(writer).Encode(file)
+	default:
+		return fmt.Errorf("unknown snippet format %q", format)
+	}
+}
+
+func Read(reader io.Reader, format Format) ([]Snippet, error) {
+	var file library
+	switch format {
+	case FormatJSON:
+		err := json.NewDecoder(reader).Decode(&file)
+		if err != nil {
+			return nil, err
+		}
+	case FormatTOML:
+		_, err := toml.NewDecoder(reader).Decode(&file)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown snippet format %q", format)
+	}
+
+	for index, snippet := range file.Snippets {
+		if strings.TrimSpace(snippet.Name) == "" || strings.TrimSpace(snippet.SQL) == "" {
+			return nil, fmt.Errorf("snippet %d needs a name and SQL", index+1)
+		}
+	}
+	return file.Snippets, nil
+}
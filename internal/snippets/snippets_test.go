@@ -0,0 +1,214 @@
+package snippets
+
+import (
+	"bytes"
+	"database/sql"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func createTestStore(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dbConn, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = dbConn.Close()
+	})
+
+	err = EnsureSchema(dbConn)
+	if err != nil {
+		t.Fatalf("schema: %v", err)
+	}
+
+	return dbConn
+}
+
+func snippetNames(snippets []Snippet) []string {
+	names := []string{}
+	for _, snippet := range snippets {
+		names = append(names, snippet.Name+"@"+snippet.Database)
+	}
+	return names
+}
+
+func TestSaveAndList(t *testing.T) {
+	dbConn := createTestStore(t)
+
+	saved := []Snippet{
+		{Name: "slow queries", SQL: "SELECT 1", Tags: []string{"Perf", " perf", "ops"}, Database: ""},
+		{Name: "orders today", SQL: "SELECT * FROM orders", Tags: nil, Database: "/data/shop.db"},
+		{Name: "users", SQL: "SELECT * FROM users", Tags: nil, Database: "/data/crm.db"},
+		{Name: "slow queries", SQL: "SELECT 2", Tags: []string{"perf"}, Database: ""},
+	}
+	for _, snippet := range saved {
+		err := Save(dbConn, snippet)
+		if err != nil {
+			t.Fatalf("save %s: %v", snippet.Name, err)
+		}
+	}
+
+	snippets, err := List(dbConn, "/data/shop.db")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+
+	names := snippetNames(snippets)
+	if strings.Join(names, "|") != "orders today@/data/shop.db|slow queries@" {
+		t.Fatalf("unexpected snippets %q", names)
+	}
+	if snippets[1].SQL != "SELECT 2" || !slices.Equal(snippets[1].Tags, []string{"perf"}) {
+		t.Fatalf("expected the second save to replace the first, got %+v", snippets[1])
+	}
+
+	all, err := List(dbConn, "")
+	if err != nil {
+		t.Fatalf("list all: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("expected 3 snippets, got %q", snippetNames(all))
+	}
+
+	err = Delete(dbConn, snippets[0])
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	all, err = List(dbConn, "")
+	if err != nil || len(all) != 2 {
+		t.Fatalf("expected 2 snippets after delete, got %q (%v)", snippetNames(all), err)
+	}
+
+	err = Save(dbConn, Snippet{Name: " ", SQL: "SELECT 1", Tags: nil, Database: ""})
+	if err == nil {
+		t.Fatalf("expected an error for an empty name")
+	}
+}
+
+func TestWriteRead_RoundTrip(t *testing.T) {
+	snippets := []Snippet{
+		{Name: "locks", SQL: "SELECT *\nFROM pragma_lock_status -- 'quoted'", Tags: []string{"ops"}, Database: ""},
+		{Name: "orders", SQL: "SELECT \"id\" FROM orders", Tags: nil, Database: "/data/shop.db"},
+	}
+
+	for _, format := range []Format{FormatJSON, FormatTOML} {
+		var buffer bytes.Buffer
+		err := Write(&buffer, format, snippets)
+		if err != nil {
+			t.Fatalf("write %s: %v", format, err)
+		}
+
+		read, err := Read(&buffer, format)
+		if err != nil {
+			t.Fatalf("read %s: %v", format, err)
+		}
+
+		if len(read) != len(snippets) {
+			t.Fatalf("%s: expected %d snippets, got %d", format, len(snippets), len(read))
+		}
+		for index, snippet := range snippets {
+			got := read[index]
+			if got.Name != snippet.Name || got.SQL != snippet.SQL || got.Database != snippet.Database || !slices.Equal(got.Tags, snippet.Tags) {
+				t.Fatalf("%s: snippet %d: expected %+v, got %+v", format, index, snippet, got)
+			}
+		}
+	}
+}
+
+func TestRead_TOML(t *testing.T) {
+	input := `
+[[snippet]]
+name = "table sizes"
+tags = ["ops"]
+sql = '''
+SELECT name FROM sqlite_schema
+'''
+
+[[snippet]]
+name = "broken"
+`
+
+	_, err := Read(strings.NewReader(input), FormatTOML)
+	if err == nil || !strings.Contains(err.Error(), "snippet 2") {
+		t.Fatalf("expected an error for the second snippet, got %v", err)
+	}
+
+	format, err := FormatFromPath("team/Snippets.TOML")
+	if err != nil || format != FormatTOML {
+		t.Fatalf("expected toml format, got %q %v", format, err)
+	}
+
+	_, err = FormatFromPath("snippets.yaml")
+	if err == nil {
+		t.Fatalf("expected an error for an unknown extension")
+	}
+}
+
+func TestSaveAll_RollsBackOnError(t *testing.T) {
+	dbConn := createTestStore(t)
+
+	err := SaveAll(dbConn, []Snippet{
+		{Name: "first", SQL: "SELECT 1", Tags: nil, Database: ""},
+		{Name: "broken", SQL: " ", Tags: nil, Database: ""},
+	})
+	if err == nil {
+		t.Fatalf("expected an error for the snippet without SQL")
+	}
+
+	all, err := List(dbConn, "")
+	if err != nil || len(all) != 0 {
+		t.Fatalf("expected nothing saved, got %q (%v)", snippetNames(all), err)
+	}
+
+	err = SaveAll(dbConn, []Snippet{
+		{Name: "first", SQL: "SELECT 1", Tags: nil, Database: ""},
+		{Name: "second", SQL: "SELECT 2", Tags: nil, Database: "/data/shop.db"},
+	})
+	if err != nil {
+		t.Fatalf("save all: %v", err)
+	}
+	all, err = List(dbConn, "")
+	if err != nil || len(all) != 2 {
+		t.Fatalf("expected 2 snippets, got %q (%v)", snippetNames(all), err)
+	}
+}
+
+func TestPortableDatabase(t *testing.T) {
+	tests := []struct {
+		database string
+		portable string
+	}{
+		{database: "", portable: ""},
+		{database: ":memory:", portable: ":memory:"},
+		{database: "/home/ada/proj/data/app.db", portable: "data/app.db"},
+		{database: "/home/ada/other.db", portable: "../other.db"},
+		{database: "/home/ada/proj/b.csv, /home/ada/proj/a.json", portable: "a.json, b.csv"},
+	}
+
+	for _, test := range tests {
+		portable := PortableDatabase(test.database, "/home/ada/proj")
+		if portable != test.portable {
+			t.Fatalf("PortableDatabase(%q) = %q, want %q", test.database, portable, test.portable)
+		}
+	}
+
+	resolved := map[string]string{
+		"":                     "",
+		":memory:":             ":memory:",
+		"data/app.db":          "/home/bob/src/proj/data/app.db",
+		"../other.db":          "/home/bob/src/other.db",
+		"a.json, b.csv":        "/home/bob/src/proj/a.json, /home/bob/src/proj/b.csv",
+		"/srv/shared/stock.db": "/srv/shared/stock.db",
+	}
+	for portable, want := range resolved {
+		got := ResolveDatabase(portable, "/home/bob/src/proj")
+		if got != want {
+			t.Fatalf("ResolveDatabase(%q) = %q, want %q", portable, got, want)
+		}
+	}
+}
@@ -0,0 +1,450 @@
+package sqlsyntax
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type TokenKind string
+
+const (
+	TokenWhitespace TokenKind = "whitespace"
+	TokenKeyword    TokenKind = "keyword"
+	TokenIdentifier TokenKind = "identifier"
+	TokenString     TokenKind = "string"
+	TokenNumber     TokenKind = "number"
+	TokenComment    TokenKind = "comment"
+	TokenParameter  TokenKind = "parameter"
+	TokenOperator   TokenKind = "operator"
+)
+
+type Token struct {
+	Kind         TokenKind
+	Text         string
+	Start        int
+	End          int
+	Unterminated bool
+}
+
+const (
+	styleReset        = "\x1b[0m"
+	styleKeyword      = "\x1b[1;34m"
+	styleIdentifier   = "\x1b[33m"
+	styleString       = "\x1b[32m"
+	styleNumber       = "\x1b[35m"
+	styleComment      = "\x1b[36m"
+	styleParameter    = "\x1b[1;35m"
+	styleUnterminated = "\x1b[37;41m"
+)
+
+var tokenStyles = map[TokenKind]string{
+	TokenKeyword:    styleKeyword,
+	TokenIdentifier: styleIdentifier,
+	TokenString:     styleString,
+	TokenNumber:     styleNumber,
+	TokenComment:    styleComment,
+	TokenParameter:  styleParameter,
+}
+
+var operators = []string{"->>", "->", "||", "<=", ">=", "==", "!=", "<>", "<<", ">>"}
+
+func Tokenize(text string) []Token {
+	tokens := []Token{}
+	index := 0
+	for index < len(text) {
+		kind, end, unterminated := scanToken(text, index)
+		tokens = append(tokens, Token{
+			Kind:         kind,
+			Text:         text[index:end],
+			Start:        index,
+			End:          end,
+			Unterminated: unterminated,
+		})
+		index = end
+	}
+	return tokens
+}
+
+func Highlight(text string) string {
+	var builder strings.Builder
+	for _, token := range Tokenize(text) {
+		style := tokenStyles[token.Kind]
+		if token.Unterminated && token.Kind != TokenComment {
+			style = styleUnterminated
+		}
+
+		if style == "" {
+			builder.WriteString(token.Text)
+			continue
+		}
+
+		builder.WriteString(style)
+		builder.WriteString(token.Text)
+		builder.WriteString(styleReset)
+	}
+	return builder.String()
+}
+
+func FirstUnterminated(text string) (token Token, ok bool) {
+	for _, candidate := range Tokenize(text) {
+		if candidate.Unterminated && candidate.Kind != TokenComment {
+			return candidate, true
+		}
+	}
+	return token, false
+}
+
+func IsKeyword(word string) bool {
+	return keywordSet[strings.ToUpper(word)]
+}
+
+func scanToken(text string, start int) (kind TokenKind, end int, unterminated bool) {
+	r, size := utf8.DecodeRuneInString(text[start:])
+	rest := text[start:]
+
+	switch {
+	case unicode.IsSpace(r):
+		end = start + size
+		for end < len(text) {
+			next, nextSize := utf8.DecodeRuneInString(text[end:])
+			if !unicode.IsSpace(next) {
+				break
+			}
+			end += nextSize
+		}
+		return TokenWhitespace, end, false
+	case strings.HasPrefix(rest, "--"):
+		newline := strings.IndexByte(rest, '\n')
+		if newline < 0 {
+			return TokenComment, len(text), false
+		}
+		return TokenComment, start + newline, false
+	case strings.HasPrefix(rest, "/*"):
+		closing := strings.Index(rest[2:], "*/")
+		if closing < 0 {
+			return TokenComment, len(text), true
+		}
+		return TokenComment, start + closing + 4, false
+	case r == '\'':
+		end, unterminated = quotedEnd(text, start, '\'')
+		return TokenString, end, unterminated
+	case (r == 'x' || r == 'X') && strings.HasPrefix(rest[1:], "'"):
+		end, unterminated = quotedEnd(text, start+1, '\'')
+		return TokenString, end, unterminated
+	case r == '"' || r == '`':
+		end, unterminated = quotedEnd(text, start, byte(r))
+		return TokenIdentifier, end, unterminated
+	case r == '[':
+		end, unterminated = quotedEnd(text, start, ']')
+		return TokenIdentifier, end, unterminated
+	case isDigit(r) || (r == '.' && len(rest) > 1 && isDigit(rune(rest[1]))):
+		return TokenNumber, numberEnd(text, start), false
+	case r == '?':
+		end = start + 1
+		for end < len(text) && isDigit(rune(text[end])) {
+			end += 1
+		}
+		return TokenParameter, end, false
+	case (r == ':' || r == '@' || r == '$') && start+1 < len(text):
+		end = wordEnd(text, start+1)
+		if end > start+1 {
+			return TokenParameter, end, false
+		}
+	case IsIdentifierStart(r):
+		end = wordEnd(text, start)
+		if IsKeyword(text[start:end]) {
+			return TokenKeyword, end, false
+		}
+		return TokenIdentifier, end, false
+	}
+
+	for _, operator := range operators {
+		if strings.HasPrefix(rest, operator) {
+			return TokenOperator, start + len(operator), false
+		}
+	}
+	return TokenOperator, start + size, false
+}
+
+func quotedEnd(text string, start int, closing byte) (end int, unterminated bool) {
+	index := start + 1
+	for index < len(text) {
+		if text[index] != closing {
+			index += 1
+			continue
+		}
+		if closing != ']' && index+1 < len(text) && text[index+1] == closing {
+			index += 2
+			continue
+		}
+		return index + 1, false
+	}
+	return len(text), true
+}
+
+func numberEnd(text string, start int) int {
+	if strings.HasPrefix(strings.ToLower(text[start:]), "0x") {
+		end := start + 2
+		for end < len(text) && strings.ContainsRune("0123456789abcdefABCDEF", rune(text[end])) {
+			end += 1
+		}
+		return end
+	}
+
+	end := start
+	for end < len(text) && (isDigit(rune(text[end])) || text[end] == '_') {
+		end += 1
+	}
+	if end < len(text) && text[end] == '.' {
+		end += 1
+		for end < len(text) && (isDigit(rune(text[end])) || text[end] == '_') {
+			end += 1
+		}
+	}
+	if end < len(text) && (text[end] == 'e' || text[end] == 'E') {
+		exponent := end + 1
+		if exponent < len(text) && (text[exponent] == '+' || text[exponent] == '-') {
+			exponent += 1
+		}
+		if exponent < len(text) && isDigit(rune(text[exponent])) {
+			end = exponent
+			for end < len(text) && isDigit(rune(text[end])) {
+				end += 1
+			}
+		}
+	}
+	return end
+}
+
+func wordEnd(text string, start int) int {
+	end := start
+	for end < len(text) {
+		r, size := utf8.DecodeRuneInString(text[end:])
+		if !IsIdentifierPart(r) {
+			break
+		}
+		end += size
+	}
+	return end
+}
+
+func IsIdentifierStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func IsIdentifierPart(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+type Statement struct {
+	Text  string
+	Start int
+	End   int
+}
+
+var rowKeywords = map[string]bool{
+	"SELECT":  true,
+	"VALUES":  true,
+	"PRAGMA":  true,
+	"EXPLAIN": true,
+}
+
+func SplitStatements(text string) []Statement {
+	statements := []Statement{}
+	start := -1
+	end := 0
+	depth := 0
+	leading := []string{}
+
+	for _, token := range Tokenize(text) {
+		if token.Kind == TokenWhitespace || token.Kind == TokenComment {
+			continue
+		}
+
+		if token.Kind == TokenOperator && token.Text == ";" && depth == 0 {
+			if start >= 0 {
+				statements = append(statements, Statement{Text: text[start:end], Start: start, End: end})
+			}
+			start = -1
+			leading = leading[:0]
+			continue
+		}
+
+		if start < 0 {
+			start = token.Start
+		}
+		end = token.End
+
+		if token.Kind != TokenKeyword {
+			continue
+		}
+
+		word := strings.ToUpper(token.Text)
+		if len(leading) < 3 {
+			leading = append(leading, word)
+		}
+		if leading[0] != "CREATE" || !slices.Contains(leading, "TRIGGER") {
+			continue
+		}
+
+		switch word {
+		case "BEGIN", "CASE":
+			depth += 1
+		case "END":
+			depth = max(depth-1, 0)
+		}
+	}
+
+	if start >= 0 {
+		statements = append(statements, Statement{Text: text[start:end], Start: start, End: end})
+	}
+	return statements
+}
+
+func StatementAt(statements []Statement, offset int) int {
+	if len(statements) == 0 {
+		return -1
+	}
+
+	index := 0
+	for candidate, statement := range statements {
+		if statement.Start <= offset {
+			index = candidate
+		}
+	}
+	return index
+}
+
+func ReturnsRows(statement string) bool {
+	first := true
+	cte := false
+	depth := 0
+	for _, token := range Tokenize(statement) {
+		if token.Kind == TokenOperator {
+			switch token.Text {
+			case "(":
+				depth += 1
+			case ")":
+				depth = max(depth-1, 0)
+			}
+		}
+		if token.Kind != TokenKeyword {
+			if token.Kind != TokenWhitespace && token.Kind != TokenComment {
+				first = false
+			}
+			continue
+		}
+
+		word := strings.ToUpper(token.Text)
+		if first && word == "WITH" {
+			first = false
+			cte = true
+			continue
+		}
+		if (first || cte && depth == 0) && rowKeywords[word] {
+			return true
+		}
+		if word == "RETURNING" {
+			return true
+		}
+		if depth == 0 && (word == "INSERT" || word == "UPDATE" || word == "DELETE" || word == "REPLACE") {
+			cte = false
+		}
+		first = false
+	}
+	return false
+}
+
+var writeKeywords = map[string]bool{
+	"INSERT":  true,
+	"UPDATE":  true,
+	"DELETE":  true,
+	"REPLACE": true,
+}
+
+// WritesData reports whether the statement's body is INSERT, UPDATE, DELETE or
+// REPLACE, looking past a leading WITH clause. Such statements must not be run
+// again, even when RETURNING makes them return rows.
+func WritesData(statement string) bool {
+	cte := false
+	depth := 0
+	for _, token := range Tokenize(statement) {
+		if token.Kind == TokenOperator {
+			switch token.Text {
+			case "(":
+				depth += 1
+			case ")":
+				depth = max(depth-1, 0)
+			}
+		}
+		if token.Kind != TokenKeyword || depth > 0 {
+			continue
+		}
+
+		word := strings.ToUpper(token.Text)
+		if !cte && word == "WITH" {
+			cte = true
+			continue
+		}
+		if !cte || writeKeywords[word] || word == "SELECT" || word == "VALUES" {
+			return writeKeywords[word]
+		}
+	}
+	return false
+}
+
+type Parameter struct {
+	Name  string
+	Index int
+}
+
+const MaxParameterIndex = 32766
+
+func Parameters(text string) ([]Parameter, error) {
+	parameters := []Parameter{}
+	seen := map[string]bool{}
+	taken := map[int]bool{}
+	highest := 0
+
+	for _, token := range Tokenize(text) {
+		if token.Kind != TokenParameter {
+			continue
+		}
+
+		name := token.Text
+		index := 0
+		switch {
+		case name == "?":
+			index = highest + 1
+			name = "?" + strconv.Itoa(index)
+		case strings.HasPrefix(name, "?"):
+			index, _ = strconv.Atoi(name[1:])
+		case seen[name]:
+			continue
+		default:
+			index = highest + 1
+		}
+
+		if index < 1 || index > MaxParameterIndex {
+			return nil, fmt.Errorf("parameter %s is out of range: SQLite numbers parameters 1 to %d", token.Text, MaxParameterIndex)
+		}
+
+		highest = max(highest, index)
+		if seen[name] || taken[index] {
+			continue
+		}
+
+		seen[name] = true
+		taken[index] = true
+		parameters = append(parameters, Parameter{Name: name, Index: index})
+	}
+	return parameters, nil
+}
@@ -0,0 +1,288 @@
+package sqlsyntax
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func significant(tokens []Token) []Token {
+	result := []Token{}
+	for _, token := range tokens {
+		if token.Kind != TokenWhitespace {
+			result = append(result, token)
+		}
+	}
+	return result
+}
+
+func TestTokenize(t *testing.T) {
+	text := "SELECT \"first name\", x'0A', 1.5e3, 0x1F, :id, ?2 -- note\nFROM [my table] /* c */ WHERE a->>'$.b' <> 'it''s'"
+	tokens := significant(Tokenize(text))
+
+	expected := []struct {
+		kind TokenKind
+		text string
+	}{
+		{kind: TokenKeyword, text: "SELECT"},
+		{kind: TokenIdentifier, text: "\"first name\""},
+		{kind: TokenOperator, text: ","},
+		{kind: TokenString, text: "x'0A'"},
+		{kind: TokenOperator, text: ","},
+		{kind: TokenNumber, text: "1.5e3"},
+		{kind: TokenOperator, text: ","},
+		{kind: TokenNumber, text: "0x1F"},
+		{kind: TokenOperator, text: ","},
+		{kind: TokenParameter, text: ":id"},
+		{kind: TokenOperator, text: ","},
+		{kind: TokenParameter, text: "?2"},
+		{kind: TokenComment, text: "-- note"},
+		{kind: TokenKeyword, text: "FROM"},
+		{kind: TokenIdentifier, text: "[my table]"},
+		{kind: TokenComment, text: "/* c */"},
+		{kind: TokenKeyword, text: "WHERE"},
+		{kind: TokenIdentifier, text: "a"},
+		{kind: TokenOperator, text: "->>"},
+		{kind: TokenString, text: "'$.b'"},
+		{kind: TokenOperator, text: "<>"},
+		{kind: TokenString, text: "'it''s'"},
+	}
+
+	if len(tokens) != len(expected) {
+		t.Fatalf("expected %d tokens, got %d: %+v", len(expected), len(tokens), tokens)
+	}
+	for index, want := range expected {
+		got := tokens[index]
+		if got.Kind != want.kind || got.Text != want.text || got.Unterminated {
+			t.Fatalf("token %d: expected %s %q, got %+v", index, want.kind, want.text, got)
+		}
+		if text[got.Start:got.End] != got.Text {
+			t.Fatalf("token %d: offsets %d:%d do not match %q", index, got.Start, got.End, got.Text)
+		}
+	}
+}
+
+func TestTokenize_Unterminated(t *testing.T) {
+	tests := []struct {
+		text string
+		kind TokenKind
+	}{
+		{text: "SELECT 'abc", kind: TokenString},
+		{text: "SELECT \"abc", kind: TokenIdentifier},
+		{text: "SELECT [abc", kind: TokenIdentifier},
+		{text: "SELECT /* abc", kind: TokenComment},
+	}
+
+	for _, test := range tests {
+		tokens := Tokenize(test.text)
+		last := tokens[len(tokens)-1]
+		if last.Kind != test.kind || !last.Unterminated {
+			t.Fatalf("%q: expected unterminated %s, got %+v", test.text, test.kind, last)
+		}
+	}
+
+	token, ok := FirstUnterminated("SELECT 'a' || 'b")
+	if !ok || token.Text != "'b" {
+		t.Fatalf("expected unterminated 'b, got %+v %v", token, ok)
+	}
+
+	_, ok = FirstUnterminated("SELECT 1 /* open comment")
+	if ok {
+		t.Fatalf("open comments should not be reported as unbalanced quotes")
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	highlighted := Highlight("select 'a', 42 from t")
+	expected := styleKeyword + "select" + styleReset + " " +
+		styleString + "'a'" + styleReset + ", " +
+		styleNumber + "42" + styleReset + " " +
+		styleKeyword + "from" + styleReset + " " +
+		styleIdentifier + "t" + styleReset
+	if highlighted != expected {
+		t.Fatalf("unexpected highlight:\n%q\n%q", highlighted, expected)
+	}
+
+	highlighted = Highlight("SELECT 'oops")
+	if !strings.Contains(highlighted, styleUnterminated+"'oops"+styleReset) {
+		t.Fatalf("expected unterminated string to be flagged, got %q", highlighted)
+	}
+}
+
+func statementTexts(statements []Statement) []string {
+	texts := []string{}
+	for _, statement := range statements {
+		texts = append(texts, statement.Text)
+	}
+	return texts
+}
+
+func TestSplitStatements(t *testing.T) {
+	text := `-- setup
+CREATE TABLE t (a TEXT);
+INSERT INTO t VALUES ('x;y'), ("z;"); /* ; */
+CREATE TEMP TRIGGER trg AFTER INSERT ON t BEGIN
+  UPDATE t SET a = CASE WHEN a = ';' THEN 'semi' ELSE a END;
+  DELETE FROM t WHERE a IS NULL;
+END;
+;
+SELECT * FROM t`
+
+	statements := SplitStatements(text)
+	expected := []string{
+		"CREATE TABLE t (a TEXT)",
+		"INSERT INTO t VALUES ('x;y'), (\"z;\")",
+		"CREATE TEMP TRIGGER trg AFTER INSERT ON t BEGIN\n  UPDATE t SET a = CASE WHEN a = ';' THEN 'semi' ELSE a END;\n  DELETE FROM t WHERE a IS NULL;\nEND",
+		"SELECT * FROM t",
+	}
+
+	texts := statementTexts(statements)
+	if strings.Join(texts, "|") != strings.Join(expected, "|") {
+		t.Fatalf("unexpected statements:\n%q", texts)
+	}
+	for _, statement := range statements {
+		if text[statement.Start:statement.End] != statement.Text {
+			t.Fatalf("offsets %d:%d do not match %q", statement.Start, statement.End, statement.Text)
+		}
+	}
+
+	if len(SplitStatements("  -- only a comment\n ; ")) != 0 {
+		t.Fatalf("expected no statements for comments and separators")
+	}
+}
+
+func TestStatementAt(t *testing.T) {
+	text := "SELECT 1;\nSELECT 2;\n\nSELECT 3"
+	statements := SplitStatements(text)
+
+	tests := []struct {
+		offset int
+		want   int
+	}{
+		{offset: 0, want: 0},
+		{offset: strings.Index(text, ";") + 1, want: 0},
+		{offset: strings.Index(text, "SELECT 2"), want: 1},
+		{offset: strings.Index(text, "SELECT 3") - 1, want: 1},
+		{offset: len(text), want: 2},
+	}
+
+	for _, test := range tests {
+		if got := StatementAt(statements, test.offset); got != test.want {
+			t.Fatalf("StatementAt(%d) = %d, want %d", test.offset, got, test.want)
+		}
+	}
+
+	if StatementAt(nil, 0) != -1 {
+		t.Fatalf("expected -1 without statements")
+	}
+}
+
+func TestSplitStatementsWithCommonTableExpressions(t *testing.T) {
+	text := `CREATE TEMP TABLE t (id INTEGER);
+WITH x AS (SELECT 1 AS id) INSERT INTO t SELECT id FROM x;
+WITH y AS (SELECT id FROM t) SELECT * FROM y;
+WITH z AS (SELECT 1 AS id) DELETE FROM t WHERE id IN (SELECT id FROM z)`
+
+	statements := SplitStatements(text)
+	if len(statements) != 4 {
+		t.Fatalf("expected 4 statements, got %q", statementTexts(statements))
+	}
+
+	returnsRows := []bool{}
+	for _, statement := range statements {
+		returnsRows = append(returnsRows, ReturnsRows(statement.Text))
+	}
+	if !slices.Equal(returnsRows, []bool{false, false, true, false}) {
+		t.Fatalf("unexpected row-returning statements %v", returnsRows)
+	}
+}
+
+func TestReturnsRows(t *testing.T) {
+	tests := []struct {
+		statement string
+		want      bool
+	}{
+		{statement: "SELECT 1", want: true},
+		{statement: "-- note\nwith x AS (SELECT 1) SELECT * FROM x", want: true},
+		{statement: "VALUES (1)", want: true},
+		{statement: "PRAGMA table_info(t)", want: true},
+		{statement: "INSERT INTO t VALUES (1)", want: false},
+		{statement: "INSERT INTO t VALUES (1) RETURNING id", want: true},
+		{statement: "UPDATE t SET a = 'select'", want: false},
+		{statement: "CREATE TABLE t (a)", want: false},
+		{statement: "WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n) VALUES ((SELECT max(i) FROM n))", want: true},
+		{statement: "WITH x AS (SELECT 1 AS id) DELETE FROM t WHERE id IN (SELECT id FROM x)", want: false},
+		{statement: "WITH x AS MATERIALIZED (SELECT 1 AS id) INSERT INTO t SELECT id FROM x", want: false},
+		{statement: "WITH x AS (SELECT 1 AS id) UPDATE t SET a = 1 WHERE id IN x RETURNING id", want: true},
+	}
+
+	for _, test := range tests {
+		if got := ReturnsRows(test.statement); got != test.want {
+			t.Fatalf("ReturnsRows(%q) = %v, want %v", test.statement, got, test.want)
+		}
+	}
+}
+
+func TestWritesData(t *testing.T) {
+	tests := []struct {
+		statement string
+		want      bool
+	}{
+		{statement: "SELECT 1", want: false},
+		{statement: "with x AS (DELETE FROM t) SELECT * FROM x", want: false},
+		{statement: "WITH x AS (SELECT 1 AS id) DELETE FROM t WHERE id IN (SELECT id FROM x) RETURNING *", want: true},
+		{statement: "WITH RECURSIVE x(id) AS (SELECT 1) INSERT INTO t SELECT id FROM x RETURNING id", want: true},
+		{statement: "WITH x AS MATERIALIZED (SELECT 1 AS id) UPDATE t SET a = 1 WHERE id IN x", want: true},
+		{statement: "-- note\nREPLACE INTO t VALUES (1) RETURNING *", want: true},
+		{statement: "EXPLAIN DELETE FROM t", want: false},
+		{statement: "PRAGMA table_info(t)", want: false},
+	}
+
+	for _, test := range tests {
+		if got := WritesData(test.statement); got != test.want {
+			t.Fatalf("WritesData(%q) = %v, want %v", test.statement, got, test.want)
+		}
+	}
+}
+
+func TestParameters(t *testing.T) {
+	text := "SELECT ? , :id, ?5, ?, @name, :id, ':skip' -- ?9\nFROM t WHERE a = ?2"
+	parameters, err := Parameters(text)
+	if err != nil {
+		t.Fatalf("Parameters: %v", err)
+	}
+	expected := []Parameter{
+		{Name: "?1", Index: 1},
+		{Name: ":id", Index: 2},
+		{Name: "?5", Index: 5},
+		{Name: "?6", Index: 6},
+		{Name: "@name", Index: 7},
+	}
+
+	if !slices.Equal(parameters, expected) {
+		t.Fatalf("unexpected parameters:\n%+v", parameters)
+	}
+
+	parameters, err = Parameters("SELECT '?' FROM t")
+	if err != nil || len(parameters) != 0 {
+		t.Fatalf("expected no parameters inside strings, got %+v, %v", parameters, err)
+	}
+}
+
+func TestParametersOutOfRange(t *testing.T) {
+	for _, text := range []string{
+		"SELECT ?0",
+		"SELECT ?999999999",
+		"SELECT ?32766, :next",
+	} {
+		parameters, err := Parameters(text)
+		if err == nil {
+			t.Fatalf("Parameters(%q) = %+v, want an out of range error", text, parameters)
+		}
+	}
+
+	parameters, err := Parameters("SELECT ?32766")
+	if err != nil || len(parameters) != 1 {
+		t.Fatalf("expected ?32766 to be accepted, got %+v, %v", parameters, err)
+	}
+}
@@ -18,9 +18,12 @@ type TableRender struct {
 }
 
 type ComputeTableConfig struct {
-	Columns []string
-	Rows    []db.SqliteRow
-	MaxRows int
+	Columns      []string
+	ColumnLabels []string
+	Rows         []db.SqliteRow
+	MaxRows      int
+	CellStyle    func(rowIndex int, colIndex int) string
+	Highlight    string
 }
 
 func ComputeTable(config ComputeTableConfig) TableRender {
@@ -32,9 +35,14 @@ func ComputeTable(config ComputeTableConfig) TableRender {
 		}
 	}
 
+	labels := config.Columns
+	if len(config.ColumnLabels) == len(config.Columns) {
+		labels = config.ColumnLabels
+	}
+
 	widths := []int{}
-	for _, col := range config.Columns {
-		widths = append(widths, stringWidth(col))
+	for _, label := range labels {
+		widths = append(widths, stringWidth(label))
 	}
 
 	for _, row := range visibleRows {
@@ -76,7 +84,7 @@ func ComputeTable(config ComputeTableConfig) TableRender {
 		}
 
 		colWidth := widths[i]
-		headerCells = append(headerCells, padRight(truncateString(key, colWidth), colWidth))
+		headerCells = append(headerCells, padRight(truncateString(labels[i], colWidth), colWidth))
 	}
 
 	header := ""
@@ -85,7 +93,7 @@ func ComputeTable(config ComputeTableConfig) TableRender {
 	}
 
 	bodyLines := []string{}
-	for _, row := range visibleRows {
+	for rowIndex, row := range visibleRows {
 		cells := []string{}
 
 		for i := 0; i < len(config.Columns); i += 1 {
@@ -94,6 +102,14 @@ func ComputeTable(config ComputeTableConfig) TableRender {
 			raw := formatCell(value)
 			clipped := truncateString(raw, widths[i])
 			cell := padRight(clipped, widths[i])
+			style := ""
+			if config.CellStyle != nil {
+				style = config.CellStyle(rowIndex, i)
+			}
+			if config.Highlight != "" {
+				cell = highlightMatches(cell, config.Highlight, style)
+			}
+			cell = styleCell(cell, style)
 			cells = append(cells, cell)
 		}
 
@@ -149,6 +165,45 @@ func formatCell(value db.SqliteValue) string {
 	}
 }
 
+func styleCell(cell string, style string) string {
+	if style == "" {
+		return cell
+	}
+
+	return style + cell + StyleReset
+}
+
+func highlightMatches(cell string, term string, restore string) string {
+	haystack := strings.ToLower(cell)
+	needle := strings.ToLower(term)
+	if len(haystack) != len(cell) || len(needle) != len(term) {
+		haystack = cell
+		needle = term
+	}
+
+	var builder strings.Builder
+	start := 0
+	for {
+		index := strings.Index(haystack[start:], needle)
+		if index < 0 {
+			break
+		}
+
+		matchStart := start + index
+		matchEnd := matchStart + len(needle)
+		builder.WriteString(cell[start:matchStart])
+		builder.WriteString(StyleMatch + cell[matchStart:matchEnd] + StyleReset + restore)
+		start = matchEnd
+	}
+
+	if start == 0 {
+		return cell
+	}
+
+	builder.WriteString(cell[start:])
+	return builder.String()
+}
+
 func truncateString(value string, maxChars int) string {
 	if maxChars <= 0 {
 		return ""
@@ -206,6 +261,15 @@ func joinLines(lines []string) string {
 	return builder.String()
 }
 
+func ProgressBar(done int64, total int64, width int) string {
+	if total <= 0 || width <= 0 {
+		return ""
+	}
+
+	filled := int(min(max(done, 0), total) * int64(width) / total)
+	return "[" + strings.Repeat("#", filled) + strings.Repeat(".", width-filled) + "]"
+}
+
 func spaces(count int) string {
 	if count <= 0 {
 		return ""
@@ -214,6 +278,15 @@ func spaces(count int) string {
 	return fmt.Sprintf("%*s", count, "")
 }
 
+const (
+	StyleReset    = "\x1b[0m"
+	StyleEdited   = "\x1b[33m"
+	StyleInserted = "\x1b[32m"
+	StyleDeleted  = "\x1b[31;9m"
+	StyleCursor   = "\x1b[7m"
+	StyleMatch    = "\x1b[30;43m"
+)
+
 const columnSeparator = " | "
 
 const columnSeparatorWidth = 3
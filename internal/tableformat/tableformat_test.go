@@ -14,9 +14,12 @@ func TestComputeTable_RendersHeaderAndRows(t *testing.T) {
 	}
 
 	out := ComputeTable(ComputeTableConfig{
-		Columns: []string{"id", "name", "active", "note"},
-		Rows:    rows,
-		MaxRows: 0,
+		Columns:      []string{"id", "name", "active", "note"},
+		ColumnLabels: nil,
+		Rows:         rows,
+		MaxRows:      0,
+		CellStyle:    nil,
+		Highlight:    "",
 	})
 
 	if out.Header == "" {
@@ -38,9 +41,12 @@ func TestComputeTable_Metadata(t *testing.T) {
 	}
 
 	out := ComputeTable(ComputeTableConfig{
-		Columns: []string{"id", "name"},
-		Rows:    rows,
-		MaxRows: 0,
+		Columns:      []string{"id", "name"},
+		ColumnLabels: nil,
+		Rows:         rows,
+		MaxRows:      0,
+		CellStyle:    nil,
+		Highlight:    "",
 	})
 
 	if out.Width <= 0 {
@@ -58,9 +64,12 @@ func TestComputeTable_TruncatesCells(t *testing.T) {
 	}
 
 	out := ComputeTable(ComputeTableConfig{
-		Columns: []string{"id", "note"},
-		Rows:    rows,
-		MaxRows: 0,
+		Columns:      []string{"id", "note"},
+		ColumnLabels: nil,
+		Rows:         rows,
+		MaxRows:      0,
+		CellStyle:    nil,
+		Highlight:    "",
 	})
 
 	if len(out.Header) == 0 {
@@ -75,3 +84,111 @@ func TestComputeTable_TruncatesCells(t *testing.T) {
 		t.Fatalf("expected truncated body")
 	}
 }
+
+func TestComputeTable_CellStyle(t *testing.T) {
+	rows := []db.SqliteRow{
+		{"id": int64(1), "name": "Ava"},
+		{"id": int64(2), "name": "Mateo"},
+	}
+
+	out := ComputeTable(ComputeTableConfig{
+		Columns:      []string{"id", "name"},
+		ColumnLabels: nil,
+		Rows:         rows,
+		MaxRows:      0,
+		CellStyle: func(rowIndex int, colIndex int) string {
+			if rowIndex == 1 && colIndex == 1 {
+				return StyleEdited
+			}
+			return ""
+		},
+		Highlight: "",
+	})
+
+	lines := strings.Split(out.Body, "\n")
+	if strings.Contains(lines[0], StyleEdited) {
+		t.Fatalf("expected first row unstyled, got %q", lines[0])
+	}
+
+	if !strings.Contains(lines[1], StyleEdited+"Mateo") || !strings.HasSuffix(lines[1], StyleReset) {
+		t.Fatalf("expected styled cell, got %q", lines[1])
+	}
+}
+
+func TestComputeTable_ColumnLabels(t *testing.T) {
+	rows := []db.SqliteRow{
+		{"id": int64(1), "name": "Ava"},
+	}
+
+	out := ComputeTable(ComputeTableConfig{
+		Columns:      []string{"id", "name"},
+		ColumnLabels: []string{"id", "name ^1"},
+		Rows:         rows,
+		MaxRows:      0,
+		CellStyle:    nil,
+		Highlight:    "",
+	})
+
+	if !strings.Contains(out.Header, "name ^1") {
+		t.Fatalf("expected labelled header, got %q", out.Header)
+	}
+
+	if out.ColumnWidths[1] != len("name ^1") {
+		t.Fatalf("expected label to size the column, got %v", out.ColumnWidths)
+	}
+
+	if !strings.Contains(out.Body, "Ava") {
+		t.Fatalf("expected row values keyed by column, got %q", out.Body)
+	}
+}
+
+func TestComputeTable_Highlight(t *testing.T) {
+	rows := []db.SqliteRow{
+		{"id": int64(1), "name": "Anna"},
+		{"id": int64(2), "name": "Mateo"},
+	}
+
+	out := ComputeTable(ComputeTableConfig{
+		Columns:      []string{"id", "name"},
+		ColumnLabels: nil,
+		Rows:         rows,
+		MaxRows:      0,
+		CellStyle: func(rowIndex int, colIndex int) string {
+			if rowIndex == 0 && colIndex == 1 {
+				return StyleEdited
+			}
+			return ""
+		},
+		Highlight: "an",
+	})
+
+	lines := strings.Split(out.Body, "\n")
+	want := StyleEdited + StyleMatch + "An" + StyleReset + StyleEdited + "na"
+	if !strings.Contains(lines[0], want) {
+		t.Fatalf("expected highlighted match restoring the cell style, got %q", lines[0])
+	}
+
+	if strings.Contains(lines[1], StyleMatch) {
+		t.Fatalf("expected no highlight without a match, got %q", lines[1])
+	}
+}
+
+func TestProgressBar(t *testing.T) {
+	tests := []struct {
+		done  int64
+		total int64
+		want  string
+	}{
+		{done: 0, total: 10, want: "[....]"},
+		{done: 5, total: 10, want: "[##..]"},
+		{done: 12, total: 10, want: "[####]"},
+		{done: 5, total: 0, want: ""},
+	}
+
+	for _, test := range tests {
+		got := ProgressBar(test.done, test.total, 4)
+		if got != test.want {
+			t.Fatalf("ProgressBar(%d, %d) = %q, want %q", test.done, test.total, got, test.want)
+		}
+	}
+}